@@ -0,0 +1,160 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+// twoCliques builds two 4-node cliques joined by a single edge (3-4), plus
+// an isolated node.
+func twoCliques() *Graph {
+	g := NewGraph()
+	for i := 0; i < 9; i++ {
+		g.AddNode(Node{Kind: "n", Key: string(rune('a' + i))})
+	}
+	for _, clique := range [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}} {
+		for x := range clique {
+			for y := x + 1; y < len(clique); y++ {
+				g.AddEdge(clique[x], clique[y], 1)
+			}
+		}
+	}
+	g.AddEdge(3, 4, 1)
+	return g
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestGraph_AddNodeAndEdge(t *testing.T) {
+	g := NewGraph()
+	a := g.AddNode(Node{Kind: "country", Key: "BRA"})
+	b := g.AddNode(Node{Kind: "vaccine", Key: "BRA"})
+	if again := g.AddNode(Node{Kind: "country", Key: "BRA", Name: "Brazil"}); again != a {
+		t.Errorf("expected the existing node %d, got %d", a, again)
+	}
+	g.AddEdge(a, b, 1)
+	g.AddEdge(b, a, 2)
+	g.AddEdge(a, a, 5)
+	if g.Len() != 2 || g.EdgeCount() != 1 || g.adj[a][b] != 3 {
+		t.Errorf("unexpected graph: %d nodes, %d edges, weight %v", g.Len(), g.EdgeCount(), g.adj[a][b])
+	}
+}
+
+func TestProject(t *testing.T) {
+	country := func(key string) Node { return Node{Kind: CountryKind, Key: key} }
+	vaccine := func(key string) Node { return Node{Kind: VaccineKind, Key: key} }
+	uses := []Use{
+		{country("BRA"), vaccine("pfizer")},
+		{country("BRA"), vaccine("sinovac")},
+		{country("ARG"), vaccine("pfizer")},
+		{country("ARG"), vaccine("sinovac")},
+		{country("ARG"), vaccine("sputnik")},
+		{country("CHN"), vaccine("sinovac")},
+	}
+
+	g, _ := Project(Uses, uses, 0)
+	if g.Len() != 6 || g.EdgeCount() != 6 {
+		t.Errorf("uses: expected 6 nodes and 6 edges, got %d and %d", g.Len(), g.EdgeCount())
+	}
+
+	g, _ = Project(CountrySimilarity, uses, 0)
+	bra, arg, chn := g.index[nodeID{CountryKind, "BRA"}], g.index[nodeID{CountryKind, "ARG"}], g.index[nodeID{CountryKind, "CHN"}]
+	if w := g.adj[bra][arg]; !almostEqual(w, 2.0/3) {
+		t.Errorf("expected BRA-ARG similarity 2/3, got %v", w)
+	}
+	if w := g.adj[bra][chn]; !almostEqual(w, 0.5) {
+		t.Errorf("expected BRA-CHN similarity 1/2, got %v", w)
+	}
+
+	g, _ = Project(CountrySimilarity, uses, 0.6)
+	if g.EdgeCount() != 1 {
+		t.Errorf("expected only BRA-ARG above 0.6, got %d edges", g.EdgeCount())
+	}
+
+	g, _ = Project(VaccineSimilarity, uses, 0)
+	pfizer, sinovac := g.index[nodeID{VaccineKind, "pfizer"}], g.index[nodeID{VaccineKind, "sinovac"}]
+	if w := g.adj[pfizer][sinovac]; !almostEqual(w, 2.0/3) {
+		t.Errorf("expected pfizer-sinovac similarity 2/3, got %v", w)
+	}
+
+	if _, ok := Project("unknown", uses, 0); ok {
+		t.Error("expected an unknown projection to be rejected")
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	rank := PageRank(twoCliques())
+	sum := 0.0
+	for _, r := range rank {
+		sum += r
+	}
+	if !almostEqual(sum, 1) {
+		t.Errorf("expected ranks to sum to 1, got %v", sum)
+	}
+	// The bridge nodes have one more edge than the others
+	if rank[3] <= rank[0] || !almostEqual(rank[3], rank[4]) || !almostEqual(rank[0], rank[7]) {
+		t.Errorf("unexpected ranks: %v", rank)
+	}
+	if rank[8] >= rank[0] {
+		t.Errorf("expected the isolated node to rank lowest, got %v", rank)
+	}
+}
+
+func TestConnectedComponents(t *testing.T) {
+	component := ConnectedComponents(twoCliques())
+	for i := 0; i < 8; i++ {
+		if component[i] != 0 {
+			t.Errorf("expected node %d in component 0, got %d", i, component[i])
+		}
+	}
+	if component[8] != 1 {
+		t.Errorf("expected the isolated node in component 1, got %d", component[8])
+	}
+}
+
+func TestLouvain(t *testing.T) {
+	g := twoCliques()
+	community, q := Louvain(g)
+	want := []int{0, 0, 0, 0, 1, 1, 1, 1, 2}
+	for i := range want {
+		if community[i] != want[i] {
+			t.Fatalf("expected communities %v, got %v", want, community)
+		}
+	}
+	// 13 edges (26 counted from both ends): each clique holds 12 of the 26
+	// and has a total degree of 13.
+	if expected := 2 * (12.0/26 - (13.0/26)*(13.0/26)); !almostEqual(q, expected) {
+		t.Errorf("expected modularity %v, got %v", expected, q)
+	}
+
+	if community, q := Louvain(NewGraph()); len(community) != 0 || q != 0 {
+		t.Errorf("expected nothing for an empty graph, got %v, %v", community, q)
+	}
+}
+
+func TestCentrality(t *testing.T) {
+	g := twoCliques()
+
+	degree := Degree(g)
+	if !almostEqual(degree[3], 4.0/8) || !almostEqual(degree[0], 3.0/8) || degree[8] != 0 {
+		t.Errorf("unexpected degrees: %v", degree)
+	}
+
+	betweenness := Betweenness(g)
+	// Bridge node 3 lies on every shortest path from 0, 1 and 2 to the other
+	// clique: 3*4 of the 8*7/2 pairs of other nodes
+	if !almostEqual(betweenness[3], 12.0/28) || betweenness[0] != 0 || betweenness[8] != 0 {
+		t.Errorf("unexpected betweenness: %v", betweenness)
+	}
+
+	closeness := Closeness(g)
+	if closeness[3] <= closeness[0] || closeness[8] != 0 {
+		t.Errorf("unexpected closeness: %v", closeness)
+	}
+
+	if strength := Strength(g); strength[3] != 4 {
+		t.Errorf("expected strength 4, got %v", strength[3])
+	}
+}
@@ -0,0 +1,124 @@
+// Package analytics runs graph algorithms in Go over projections of the
+// loaded graph.
+//
+// This file implements degree, closeness and betweenness centrality. Edge
+// weights are ignored except by Strength: distances count hops.
+
+package analytics
+
+// Degree returns the number of neighbours of every node, divided by the
+// number of other nodes.
+func Degree(g *Graph) []float64 {
+	n := g.Len()
+	degree := make([]float64, n)
+	if n < 2 {
+		return degree
+	}
+	for i, neighbours := range g.adj {
+		degree[i] = float64(len(neighbours)) / float64(n-1)
+	}
+	return degree
+}
+
+// Strength returns the sum of the edge weights of every node.
+func Strength(g *Graph) []float64 {
+	strength := make([]float64, g.Len())
+	for i, neighbours := range g.adj {
+		for _, w := range neighbours {
+			strength[i] += w
+		}
+	}
+	return strength
+}
+
+// Closeness returns the harmonic closeness of every node: the mean of the
+// inverse distances to the other nodes, which is well defined on
+// disconnected graphs (unreachable nodes add 0).
+func Closeness(g *Graph) []float64 {
+	n := g.Len()
+	neighbours := g.neighbours()
+	closeness := make([]float64, n)
+	if n < 2 {
+		return closeness
+	}
+	distance := make([]int, n)
+	for source := range closeness {
+		for i := range distance {
+			distance[i] = -1
+		}
+		distance[source] = 0
+		queue := []int{source}
+		sum := 0.0
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, e := range neighbours[i] {
+				if distance[e.to] < 0 {
+					distance[e.to] = distance[i] + 1
+					sum += 1 / float64(distance[e.to])
+					queue = append(queue, e.to)
+				}
+			}
+		}
+		closeness[source] = sum / float64(n-1)
+	}
+	return closeness
+}
+
+// Betweenness returns the betweenness centrality of every node (Brandes'
+// algorithm): the share of shortest paths between other pairs of nodes
+// that go through it, normalised to [0, 1].
+func Betweenness(g *Graph) []float64 {
+	n := g.Len()
+	neighbours := g.neighbours()
+	betweenness := make([]float64, n)
+
+	distance := make([]int, n)
+	paths := make([]float64, n)
+	dependency := make([]float64, n)
+	predecessors := make([][]int, n)
+	for source := 0; source < n; source++ {
+		for i := 0; i < n; i++ {
+			distance[i], paths[i], dependency[i] = -1, 0, 0
+			predecessors[i] = predecessors[i][:0]
+		}
+		distance[source], paths[source] = 0, 1
+
+		var order []int
+		queue := []int{source}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			order = append(order, i)
+			for _, e := range neighbours[i] {
+				if distance[e.to] < 0 {
+					distance[e.to] = distance[i] + 1
+					queue = append(queue, e.to)
+				}
+				if distance[e.to] == distance[i]+1 {
+					paths[e.to] += paths[i]
+					predecessors[e.to] = append(predecessors[e.to], i)
+				}
+			}
+		}
+
+		for k := len(order) - 1; k >= 0; k-- {
+			j := order[k]
+			for _, i := range predecessors[j] {
+				dependency[i] += paths[i] / paths[j] * (1 + dependency[j])
+			}
+			if j != source {
+				betweenness[j] += dependency[j]
+			}
+		}
+	}
+
+	// Each pair was counted from both ends
+	if n > 2 {
+		scale := 1 / float64((n-1)*(n-2))
+		for i := range betweenness {
+			betweenness[i] *= scale
+		}
+	}
+	return betweenness
+}
@@ -0,0 +1,37 @@
+// Package analytics runs graph algorithms in Go over projections of the
+// loaded graph.
+//
+// This file finds connected components.
+
+package analytics
+
+// ConnectedComponents returns the component of every node. Components are
+// numbered from 0 in the order of their first node.
+func ConnectedComponents(g *Graph) []int {
+	neighbours := g.neighbours()
+	component := make([]int, g.Len())
+	for i := range component {
+		component[i] = -1
+	}
+
+	count := 0
+	for start := range component {
+		if component[start] >= 0 {
+			continue
+		}
+		component[start] = count
+		queue := []int{start}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, e := range neighbours[i] {
+				if component[e.to] < 0 {
+					component[e.to] = count
+					queue = append(queue, e.to)
+				}
+			}
+		}
+		count++
+	}
+	return component
+}
@@ -0,0 +1,93 @@
+// Package analytics runs graph algorithms in Go over projections of the
+// loaded graph, since the Neo4j Graph Data Science plugin is not available.
+//
+// A projection is an undirected, weighted graph built from relationships
+// read from Neo4j (e.g. the bipartite Country-[:USES]->Vaccine graph, or
+// countries linked by the similarity of their vaccine portfolios). The
+// algorithms (PageRank, Louvain, connected components and centralities)
+// only depend on this package, so they are tested without a database.
+
+package analytics
+
+import "sort"
+
+// Node is a vertex of a projection, identified by its kind (e.g. "country")
+// and its key within that kind (e.g. "BRA").
+type Node struct {
+	Kind string
+	Key  string
+	Name string
+}
+
+type nodeID struct{ kind, key string }
+
+// edge is a weighted link to another node, by index.
+type edge struct {
+	to     int
+	weight float64
+}
+
+// Graph is an undirected, weighted graph without self-loops.
+type Graph struct {
+	Nodes []Node
+
+	index map[nodeID]int
+	adj   []map[int]float64
+}
+
+// NewGraph returns an empty graph.
+func NewGraph() *Graph {
+	return &Graph{index: map[nodeID]int{}}
+}
+
+// AddNode adds the node unless a node of the same kind and key exists, and
+// returns its index.
+func (g *Graph) AddNode(n Node) int {
+	id := nodeID{n.Kind, n.Key}
+	if i, ok := g.index[id]; ok {
+		return i
+	}
+	g.index[id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, n)
+	g.adj = append(g.adj, map[int]float64{})
+	return len(g.Nodes) - 1
+}
+
+// AddEdge links two nodes, by index. Repeated edges add up their weights,
+// and self-loops are ignored.
+func (g *Graph) AddEdge(a, b int, weight float64) {
+	if a == b {
+		return
+	}
+	g.adj[a][b] += weight
+	g.adj[b][a] += weight
+}
+
+// Len returns the number of nodes.
+func (g *Graph) Len() int {
+	return len(g.Nodes)
+}
+
+// EdgeCount returns the number of (undirected) edges.
+func (g *Graph) EdgeCount() int {
+	count := 0
+	for _, neighbours := range g.adj {
+		count += len(neighbours)
+	}
+	return count / 2
+}
+
+// neighbours returns the edges of every node, ordered by neighbour index,
+// so that the algorithms visit them in a deterministic order.
+func (g *Graph) neighbours() [][]edge {
+	lists := make([][]edge, len(g.adj))
+	for i, neighbours := range g.adj {
+		list := make([]edge, 0, len(neighbours))
+		for j, w := range neighbours {
+			list = append(list, edge{j, w})
+		}
+		sort.Slice(list, func(a, b int) bool { return list[a].to < list[b].to })
+		lists[i] = list
+	}
+	return lists
+}
@@ -0,0 +1,168 @@
+// Package analytics runs graph algorithms in Go over projections of the
+// loaded graph.
+//
+// This file implements Louvain community detection.
+
+package analytics
+
+// maxLouvainLevels bounds the number of aggregation levels; each level at
+// least halves the number of communities in practice.
+const maxLouvainLevels = 32
+
+// minModularityGain is the smallest gain worth moving a node for, which
+// keeps floating-point noise from moving nodes back and forth.
+const minModularityGain = 1e-12
+
+// level is the graph of one Louvain level, whose nodes are the communities
+// of the previous level. Self-loops hold the weight inside a community.
+type level struct {
+	neighbours [][]edge
+	strength   []float64
+}
+
+// Louvain returns the community of every node, numbered from 0 in the
+// order of their first node, and the modularity of the partition.
+//
+// Each level moves nodes, one at a time and in index order, to the
+// neighbouring community with the largest modularity gain until no move
+// improves it, then merges each community into a node of the next level.
+// The visiting order makes the result deterministic.
+func Louvain(g *Graph) ([]int, float64) {
+	n := g.Len()
+	community := make([]int, n)
+	for i := range community {
+		community[i] = i
+	}
+
+	current := level{neighbours: g.neighbours(), strength: Strength(g)}
+	total := 0.0
+	for _, s := range current.strength {
+		total += s
+	}
+	if total == 0 {
+		return renumber(community), 0
+	}
+
+	for depth := 0; depth < maxLouvainLevels; depth++ {
+		assignment, moved := current.moveNodes(total)
+		if !moved {
+			break
+		}
+		assignment = renumber(assignment)
+		for i := range community {
+			community[i] = assignment[community[i]]
+		}
+		current = current.aggregate(assignment)
+	}
+
+	community = renumber(community)
+	return community, modularity(g, community, total)
+}
+
+// moveNodes runs the local moving phase and returns the community of each
+// node of the level, and whether any node moved.
+func (l level) moveNodes(total float64) ([]int, bool) {
+	n := len(l.neighbours)
+	community := make([]int, n)
+	communityStrength := make([]float64, n)
+	for i := range community {
+		community[i] = i
+		communityStrength[i] = l.strength[i]
+	}
+
+	moved := false
+	weights := map[int]float64{}
+	var candidates []int
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n; i++ {
+			// Weight from i to each neighbouring community, in neighbour order
+			clear(weights)
+			candidates = candidates[:0]
+			for _, e := range l.neighbours[i] {
+				if e.to == i {
+					continue
+				}
+				c := community[e.to]
+				if _, seen := weights[c]; !seen {
+					candidates = append(candidates, c)
+				}
+				weights[c] += e.weight
+			}
+
+			own := community[i]
+			communityStrength[own] -= l.strength[i]
+			best := own
+			bestGain := weights[own] - communityStrength[own]*l.strength[i]/total
+			for _, c := range candidates {
+				gain := weights[c] - communityStrength[c]*l.strength[i]/total
+				if gain > bestGain+minModularityGain {
+					best, bestGain = c, gain
+				}
+			}
+			communityStrength[best] += l.strength[i]
+			if best != own {
+				community[i] = best
+				improved, moved = true, true
+			}
+		}
+	}
+	return community, moved
+}
+
+// aggregate builds the next level, with one node per community.
+func (l level) aggregate(community []int) level {
+	count := 0
+	for _, c := range community {
+		count = max(count, c+1)
+	}
+	weights := make([]map[int]float64, count)
+	for i := range weights {
+		weights[i] = map[int]float64{}
+	}
+	strength := make([]float64, count)
+	for i, list := range l.neighbours {
+		strength[community[i]] += l.strength[i]
+		for _, e := range list {
+			weights[community[i]][community[e.to]] += e.weight
+		}
+	}
+
+	g := Graph{adj: weights}
+	return level{neighbours: g.neighbours(), strength: strength}
+}
+
+// modularity returns the modularity of the partition of g, whose total
+// edge weight counted from both ends is total.
+func modularity(g *Graph, community []int, total float64) float64 {
+	inside := map[int]float64{}
+	strength := map[int]float64{}
+	for i, neighbours := range g.adj {
+		for j, w := range neighbours {
+			if community[i] == community[j] {
+				inside[community[i]] += w
+			}
+			strength[community[i]] += w
+		}
+	}
+	q := 0.0
+	for c, s := range strength {
+		q += inside[c]/total - (s/total)*(s/total)
+	}
+	return q
+}
+
+// renumber numbers the communities from 0 in the order of their first node.
+func renumber(community []int) []int {
+	ids := map[int]int{}
+	renumbered := make([]int, len(community))
+	for i, c := range community {
+		id, ok := ids[c]
+		if !ok {
+			id = len(ids)
+			ids[c] = id
+		}
+		renumbered[i] = id
+	}
+	return renumbered
+}
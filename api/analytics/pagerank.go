@@ -0,0 +1,64 @@
+// Package analytics runs graph algorithms in Go over projections of the
+// loaded graph.
+//
+// This file implements weighted PageRank.
+
+package analytics
+
+import "math"
+
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+)
+
+// PageRank returns the PageRank of every node, summing to 1. Each edge is
+// followed in both directions, proportionally to its weight; nodes without
+// edges spread their rank evenly over the graph.
+func PageRank(g *Graph) []float64 {
+	n := g.Len()
+	if n == 0 {
+		return nil
+	}
+	neighbours := g.neighbours()
+	strength := make([]float64, n)
+	for i, list := range neighbours {
+		for _, e := range list {
+			strength[i] += e.weight
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iteration := 0; iteration < pageRankIterations; iteration++ {
+		dangling := 0.0
+		for i := range rank {
+			if strength[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, list := range neighbours {
+			for _, e := range list {
+				next[e.to] += pageRankDamping * rank[i] * e.weight / strength[i]
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+	return rank
+}
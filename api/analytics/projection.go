@@ -0,0 +1,96 @@
+// Package analytics runs graph algorithms in Go over projections of the
+// loaded graph.
+//
+// This file builds the projections from the (country, vaccine) pairs of the
+// USES relationships.
+
+package analytics
+
+// Projection names, as accepted by the analytics endpoints.
+const (
+	Uses              = "uses"
+	CountrySimilarity = "country-similarity"
+	VaccineSimilarity = "vaccine-similarity"
+)
+
+// Projections lists the available projections.
+var Projections = []string{Uses, CountrySimilarity, VaccineSimilarity}
+
+// Node kinds.
+const (
+	CountryKind = "country"
+	VaccineKind = "vaccine"
+)
+
+// Use is a Country-[:USES]->Vaccine relationship.
+type Use struct {
+	Country Node
+	Vaccine Node
+}
+
+// Project builds the named projection from the USES relationships. For the
+// similarity projections, two countries (or vaccines) are linked when they
+// share at least one vaccine (or country) and the Jaccard similarity of
+// their sets is at least minSimilarity; the similarity is the edge weight.
+// It returns false for an unknown projection.
+func Project(name string, uses []Use, minSimilarity float64) (*Graph, bool) {
+	switch name {
+	case Uses:
+		g := NewGraph()
+		for _, use := range uses {
+			g.AddEdge(g.AddNode(use.Country), g.AddNode(use.Vaccine), 1)
+		}
+		return g, true
+	case CountrySimilarity:
+		return similarity(uses, func(u Use) (Node, Node) { return u.Country, u.Vaccine }, minSimilarity), true
+	case VaccineSimilarity:
+		return similarity(uses, func(u Use) (Node, Node) { return u.Vaccine, u.Country }, minSimilarity), true
+	}
+	return nil, false
+}
+
+// similarity links the nodes on one side of the bipartite graph (as given
+// by side, which also returns the node on the other side) by the Jaccard
+// similarity of their neighbourhoods.
+func similarity(uses []Use, side func(Use) (Node, Node), minSimilarity float64) *Graph {
+	g := NewGraph()
+	degree := map[int]int{}
+	members := map[nodeID][]int{}
+	var others []nodeID
+	for _, use := range uses {
+		node, other := side(use)
+		i := g.AddNode(node)
+		degree[i]++
+		id := nodeID{other.Kind, other.Key}
+		if _, ok := members[id]; !ok {
+			others = append(others, id)
+		}
+		members[id] = append(members[id], i)
+	}
+
+	// Count the neighbours shared by each pair, through every node on the
+	// other side, in the order they were first seen.
+	shared := map[[2]int]int{}
+	var pairs [][2]int
+	for _, id := range others {
+		list := members[id]
+		for x := 0; x < len(list); x++ {
+			for y := x + 1; y < len(list); y++ {
+				pair := [2]int{min(list[x], list[y]), max(list[x], list[y])}
+				if shared[pair] == 0 {
+					pairs = append(pairs, pair)
+				}
+				shared[pair]++
+			}
+		}
+	}
+
+	for _, pair := range pairs {
+		common := shared[pair]
+		jaccard := float64(common) / float64(degree[pair[0]]+degree[pair[1]]-common)
+		if jaccard >= minSimilarity {
+			g.AddEdge(pair[0], pair[1], jaccard)
+		}
+	}
+	return g
+}
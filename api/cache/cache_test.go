@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU[string, int](2)
+	lru.Add("a", 1)
+	lru.Add("b", 2)
+	lru.Get("a")
+	lru.Add("c", 3)
+
+	if _, ok := lru.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if v, ok := lru.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a=1 to be kept, got %v, %v", v, ok)
+	}
+	if lru.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", lru.Len())
+	}
+}
+
+// newTestRouter mounts a counting handler behind the response cache,
+// with a dataset version controlled by the returned pointer.
+func newTestRouter(version *string) (http.Handler, *int) {
+	calls := 0
+	loadedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rc := NewResponseCache(16, 0, func(context.Context) (DatasetVersion, error) {
+		return DatasetVersion{ID: *version, LoadedAt: loadedAt}, nil
+	})
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(rc.Middleware)
+		r.Get("/covid-stats/{date}", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"cases":1}`))
+		})
+	})
+	return r, &calls
+}
+
+func TestResponseCache_HitAndInvalidation(t *testing.T) {
+	version := "v1"
+	r, calls := newTestRouter(&version)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	first := get("/covid-stats/2021-07-31?b=2&a=1")
+	second := get("/covid-stats/2021-07-31?a=1&b=2")
+	if *calls != 1 {
+		t.Errorf("expected handler to run once for equivalent requests, ran %d times", *calls)
+	}
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() {
+		t.Errorf("expected cached response, got %q (%s)", second.Body.String(), second.Header().Get("X-Cache"))
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Error("expected cached headers to be replayed")
+	}
+
+	version = "v2"
+	third := get("/covid-stats/2021-07-31?a=1&b=2")
+	if *calls != 2 {
+		t.Errorf("expected a new dataset version to invalidate the cache, handler ran %d times", *calls)
+	}
+	if third.Header().Get("ETag") == first.Header().Get("ETag") {
+		t.Error("expected a new ETag for a new dataset version")
+	}
+}
+
+func TestResponseCache_ConditionalRequests(t *testing.T) {
+	version := "v1"
+	r, calls := newTestRouter(&version)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31", nil))
+	etag := rec.Header().Get("ETag")
+	lastModified := rec.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("expected ETag and Last-Modified, got %q and %q", etag, lastModified)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching If-None-Match, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for If-Modified-Since, got %d", rec.Code)
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected conditional requests not to run the handler, ran %d times", *calls)
+	}
+}
+
+func TestResponseCache_IfNoneMatchAny(t *testing.T) {
+	version := "v1"
+	r, calls := newTestRouter(&version)
+
+	anyMatch := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("If-None-Match", "*")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Nothing is known about the resource yet: the handler answers
+	if rec := anyMatch("/covid-stats/2021-07-31"); rec.Code != http.StatusOK || *calls != 1 {
+		t.Errorf("expected the handler to run on a miss, got %d after %d calls", rec.Code, *calls)
+	}
+	if rec := anyMatch("/covid-stats/2021-07-31"); rec.Code != http.StatusNotModified || *calls != 1 {
+		t.Errorf("expected 304 once the representation is cached, got %d after %d calls", rec.Code, *calls)
+	}
+	if rec := anyMatch("/unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a resource without representation, got %d", rec.Code)
+	}
+}
+
+func TestResponseCache_IfModifiedSinceOnMiss(t *testing.T) {
+	version := "v1"
+	r, calls := newTestRouter(&version)
+
+	ifModifiedSince := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := ifModifiedSince("/unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a resource without representation, got %d", rec.Code)
+	}
+	if rec := ifModifiedSince("/covid-stats/2021-07-31"); rec.Code != http.StatusOK || *calls != 1 {
+		t.Errorf("expected the handler to run on a miss, got %d after %d calls", rec.Code, *calls)
+	}
+	if rec := ifModifiedSince("/covid-stats/2021-07-31"); rec.Code != http.StatusNotModified || *calls != 1 {
+		t.Errorf("expected 304 once the representation is cached, got %d after %d calls", rec.Code, *calls)
+	}
+}
+
+func TestResponseCache_LastModifiedFollowsVersion(t *testing.T) {
+	current := DatasetVersion{ID: "v1", LoadedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	rc := NewResponseCache(16, 0, func(context.Context) (DatasetVersion, error) {
+		return current, nil
+	})
+	r := chi.NewRouter()
+	r.With(rc.Middleware).Get("/covid-stats/{date}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cases":1}`))
+	})
+
+	lastModified := func() string {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31", nil))
+		return rec.Header().Get("Last-Modified")
+	}
+
+	first := lastModified()
+	current.LoadedAt = current.LoadedAt.Add(time.Hour)
+	if got := lastModified(); got != first {
+		t.Errorf("expected a reload of the same version to keep Last-Modified %s, got %s", first, got)
+	}
+
+	current.ID = "v2"
+	if got := lastModified(); got == first {
+		t.Error("expected a new version to change Last-Modified")
+	}
+}
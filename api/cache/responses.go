@@ -0,0 +1,266 @@
+// Package cache provides in-process caching of handler results.
+//
+// This file implements the response cache middleware. Successful GET
+// responses are cached by normalised route and parameters, and tagged with
+// the version of the loaded dataset: when the ETL loads a new dataset the
+// version changes, the cache is purged and new ETags are issued.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/logging"
+	"github.com/biiafranca/viralgraph/api/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// metricsName labels the response cache in the cache metrics.
+	metricsName = "responses"
+
+	// maxEntryBytes bounds the size of a single cached response body.
+	maxEntryBytes = 1 << 20
+)
+
+// DatasetVersion identifies the dataset currently loaded in the database.
+type DatasetVersion struct {
+	ID       string
+	LoadedAt time.Time
+}
+
+// VersionFunc looks up the current dataset version.
+type VersionFunc func(ctx context.Context) (DatasetVersion, error)
+
+// ResponseCache caches handler responses for the current dataset version
+// and answers conditional requests with 304 Not Modified.
+type ResponseCache struct {
+	entries    *LRU[string, *entry]
+	lookup     VersionFunc
+	versionTTL time.Duration
+
+	mu        sync.Mutex
+	version   DatasetVersion
+	checkedAt time.Time
+}
+
+type entry struct {
+	header http.Header
+	body   []byte
+}
+
+// NewResponseCache creates a cache holding up to size responses.
+// The dataset version is looked up at most once every versionTTL.
+func NewResponseCache(size int, versionTTL time.Duration, lookup VersionFunc) *ResponseCache {
+	return &ResponseCache{
+		entries:    NewLRU[string, *entry](size),
+		lookup:     lookup,
+		versionTTL: versionTTL,
+	}
+}
+
+// Middleware serves GET requests from the cache when possible and stores
+// successful responses otherwise. It must be mounted on routed handlers
+// (e.g. inside a chi Group) so that the route pattern is known.
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version, ok := c.currentVersion(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := requestKey(r)
+		etag := entityTag(version, key)
+		lastModified := version.LoadedAt.UTC().Truncate(time.Second)
+
+		w.Header().Set("ETag", etag)
+		if !lastModified.IsZero() {
+			w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+
+		cached, hit := c.entries.Get(key)
+		if notModified(r, etag, lastModified, hit) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if hit {
+			metrics.CacheHit(metricsName)
+			for name, values := range cached.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(http.StatusOK)
+			w.Write(cached.body)
+			return
+		}
+		metrics.CacheMiss(metricsName)
+
+		w.Header().Set("X-Cache", "MISS")
+		rec := &bufferingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == http.StatusOK && !rec.overflow {
+			header := w.Header().Clone()
+			for _, name := range []string{"ETag", "Last-Modified", "X-Cache", logging.RequestIDHeader} {
+				header.Del(name)
+			}
+			c.entries.Add(key, &entry{header: header, body: rec.buf.Bytes()})
+		}
+	})
+}
+
+// currentVersion returns the dataset version, refreshing it when the TTL
+// has expired. A version change purges every cached response. The load
+// time of a version is kept until its ID changes, so that reloading the
+// same dataset does not move Last-Modified.
+func (c *ResponseCache) currentVersion(ctx context.Context) (DatasetVersion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.versionTTL {
+		return c.version, c.version.ID != ""
+	}
+	c.checkedAt = time.Now()
+
+	version, err := c.lookup(ctx)
+	if err != nil || version.ID == "" {
+		// Without a known version responses cannot be safely cached.
+		logging.FromContext(ctx).Warn("Dataset version unavailable, response cache bypassed", "error", err)
+		c.version = DatasetVersion{}
+		c.entries.Purge()
+		return DatasetVersion{}, false
+	}
+
+	if version.ID != c.version.ID {
+		c.entries.Purge()
+		c.version = version
+	}
+	return c.version, true
+}
+
+// requestKey normalises a request into a cache key made of the route
+// pattern, the URL parameters in route order, the sorted query string and
+// the current day.
+func requestKey(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		b.WriteString(r.URL.Path)
+	} else {
+		b.WriteString(rctx.RoutePattern())
+		for i, name := range rctx.URLParams.Keys {
+			fmt.Fprintf(&b, "|%s=%s", name, rctx.URLParams.Values[i])
+		}
+	}
+
+	query := r.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			fmt.Fprintf(&b, "&%s=%s", url.QueryEscape(name), url.QueryEscape(value))
+		}
+	}
+
+	// Relative dates (today, -7d) and the default end of series resolve to
+	// a different date every day.
+	fmt.Fprintf(&b, "#day=%s", time.Now().Format("2006-01-02"))
+
+	// Alternative representations of the same resource get their own entry.
+	if accept := r.Header.Get("Accept"); accept != "" {
+		fmt.Fprintf(&b, "#accept=%s", accept)
+	}
+
+	return b.String()
+}
+
+// entityTag derives a strong ETag from the dataset version and the request key,
+// so it can be computed without executing the handler.
+func entityTag(version DatasetVersion, key string) string {
+	sum := sha256.Sum256([]byte(version.ID + "\x00" + key))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// when no entity tags were sent (RFC 9110, section 13.2.2). "*" and
+// If-Modified-Since only apply when a current representation is known to
+// exist, i.e. on a cache hit; otherwise the handler decides (it may well
+// answer 400 or 404).
+func notModified(r *http.Request, etag string, lastModified time.Time, exists bool) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if (candidate == "*" && exists) || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && exists && !lastModified.IsZero() {
+		if since, err := http.ParseTime(ims); err == nil {
+			return !lastModified.After(since)
+		}
+	}
+	return false
+}
+
+// bufferingWriter passes the response through while keeping a copy of
+// the body, up to maxEntryBytes, for the cache.
+type bufferingWriter struct {
+	http.ResponseWriter
+	status   int
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (bw *bufferingWriter) WriteHeader(code int) {
+	bw.status = code
+	if code != http.StatusOK {
+		bw.Header().Del("ETag")
+		bw.Header().Del("Last-Modified")
+	}
+	bw.ResponseWriter.WriteHeader(code)
+}
+
+func (bw *bufferingWriter) Write(b []byte) (int, error) {
+	if !bw.overflow {
+		if bw.buf.Len()+len(b) > maxEntryBytes {
+			bw.overflow = true
+			bw.buf.Reset()
+		} else {
+			bw.buf.Write(b)
+		}
+	}
+	return bw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (bw *bufferingWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
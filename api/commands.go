@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/biiafranca/viralgraph/api/dump"
+	"github.com/biiafranca/viralgraph/api/migrations"
+)
+
+const usage = `Usage: viralgraph [command]
+
+Commands:
+  serve            Start the API server (default)
+  dump <dir>       Write every node and relationship to a new dump in dir
+  restore <dir>    Load the dump in dir into an empty database
+  migrate          Apply pending schema migrations
+`
+
+// runCommand runs a maintenance subcommand and returns the exit code.
+func runCommand(name string, args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	switch {
+	case name == "dump" && flags.NArg() == 1:
+		var manifest *dump.Manifest
+		if manifest, err = dump.Dump(ctx, flags.Arg(0)); err == nil {
+			slog.Info("Dump written", "dir", flags.Arg(0), "files", manifest.Files, "dataset_version", manifest.DatasetVersion)
+		}
+	case name == "restore" && flags.NArg() == 1:
+		var manifest *dump.Manifest
+		if manifest, err = dump.Restore(ctx, flags.Arg(0)); err == nil {
+			slog.Info("Dump restored", "dir", flags.Arg(0), "files", manifest.Files, "dataset_version", manifest.DatasetVersion)
+		}
+	case name == "migrate" && flags.NArg() == 0:
+		var applied []migrations.Migration
+		if applied, err = migrations.Apply(ctx); err == nil {
+			slog.Info("Schema up to date", "applied", len(applied), "version", migrations.Latest())
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	if err != nil {
+		slog.Error("Command failed", "command", name, "error", err)
+		return 1
+	}
+	return 0
+}
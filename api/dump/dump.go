@@ -0,0 +1,164 @@
+// Package dump snapshots the whole graph to files and restores it into an
+// empty database, without rerunning the ETL.
+//
+// This file writes dumps. Nodes and relationships are streamed from the
+// database straight into the compressed files, so a dump never holds the
+// graph in memory. SchemaMigration nodes are left out: the schema is
+// recreated by the migrations on restore.
+
+package dump
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/migrations"
+	"github.com/biiafranca/viralgraph/api/neo4j"
+)
+
+// Dump writes every node and relationship to a new dump in dir, which is
+// created if needed and must not already hold a dump.
+//
+// Nodes and relationships are read in separate transactions, so the
+// database should not be written to (e.g. by the ETL) while it runs.
+func Dump(ctx context.Context, dir string) (*Manifest, error) {
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		return nil, fmt.Errorf("%s already holds a dump", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	schemaVersion, err := migrations.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	datasetVersion, _, err := neo4j.DatasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{
+		Format:            Format,
+		FormatVersion:     FormatVersion,
+		SchemaVersion:     schemaVersion,
+		DatasetVersion:    datasetVersion,
+		CreatedAt:         time.Now().UTC(),
+		Labels:            map[string]int{},
+		RelationshipTypes: map[string]int{},
+	}
+
+	nodes, err := writeFile(dir, NodesFile, func(enc *json.Encoder) (int, error) {
+		return dumpNodes(ctx, enc, manifest.Labels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := writeFile(dir, RelationshipsFile, func(enc *json.Encoder) (int, error) {
+		return dumpRelationships(ctx, enc, manifest.RelationshipTypes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	manifest.Files = []File{nodes, relationships}
+
+	// The manifest is written last, so an interrupted dump has none and
+	// cannot be restored.
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), append(data, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeFile creates a gzip-compressed NDJSON file, filled by write, and
+// returns its manifest entry.
+func writeFile(dir, name string, write func(enc *json.Encoder) (int, error)) (File, error) {
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, hash))
+	records, err := write(json.NewEncoder(gz))
+	if err != nil {
+		return File{}, fmt.Errorf("writing %s: %w", name, err)
+	}
+	if err := gz.Close(); err != nil {
+		return File{}, err
+	}
+	if err := f.Close(); err != nil {
+		return File{}, err
+	}
+	return File{Name: name, Records: records, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func dumpNodes(ctx context.Context, enc *json.Encoder, labels map[string]int) (int, error) {
+	query := `
+		MATCH (n)
+		WHERE NOT n:SchemaMigration
+		RETURN elementId(n) AS id, labels(n) AS labels, properties(n) AS properties
+	`
+	count := 0
+	err := neo4j.Stream(ctx, "dump.nodes", query, nil, func(record *neo4j.Record) error {
+		id, _ := record.Get("id")
+		rawLabels, _ := record.Get("labels")
+		rawProperties, _ := record.Get("properties")
+
+		properties, err := encodeProperties(rawProperties.(map[string]interface{}))
+		if err != nil {
+			return fmt.Errorf("node %v: %w", id, err)
+		}
+		node := NodeRecord{ID: id.(string), Properties: properties}
+		for _, label := range rawLabels.([]interface{}) {
+			node.Labels = append(node.Labels, label.(string))
+			labels[label.(string)]++
+		}
+		count++
+		return enc.Encode(node)
+	})
+	return count, err
+}
+
+func dumpRelationships(ctx context.Context, enc *json.Encoder, types map[string]int) (int, error) {
+	query := `
+		MATCH (a)-[r]->(b)
+		WHERE NOT a:SchemaMigration AND NOT b:SchemaMigration
+		RETURN type(r) AS type, elementId(a) AS start, elementId(b) AS end,
+			properties(r) AS properties
+	`
+	count := 0
+	err := neo4j.Stream(ctx, "dump.relationships", query, nil, func(record *neo4j.Record) error {
+		kind, _ := record.Get("type")
+		start, _ := record.Get("start")
+		end, _ := record.Get("end")
+		rawProperties, _ := record.Get("properties")
+
+		properties, err := encodeProperties(rawProperties.(map[string]interface{}))
+		if err != nil {
+			return fmt.Errorf("%v relationship: %w", kind, err)
+		}
+		relationship := RelationshipRecord{
+			Type:       kind.(string),
+			Start:      start.(string),
+			End:        end.(string),
+			Properties: properties,
+		}
+		types[relationship.Type]++
+		count++
+		return enc.Encode(relationship)
+	})
+	return count, err
+}
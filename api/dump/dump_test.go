@@ -0,0 +1,136 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+func TestPropertiesRoundTrip(t *testing.T) {
+	loadedAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	properties := map[string]interface{}{
+		"iso3":       "BRA",
+		"totalCases": int64(34_000_000),
+		"rate":       2.0,
+		"nan":        math.NaN(),
+		"flag":       true,
+		"missing":    nil,
+		"date":       dbtype.Date(time.Date(2021, 1, 17, 0, 0, 0, 0, time.UTC)),
+		"loaded_at":  loadedAt,
+		"aliases":    []interface{}{"comirnaty", "bnt162b2"},
+	}
+
+	encoded, err := encodeProperties(properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"rate":{"$float":2}`) || !strings.Contains(string(data), `"date":{"$date":"2021-01-17"}`) {
+		t.Errorf("expected tagged floats and dates, got %s", data)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeProperties(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nan, _ := decoded["nan"].(float64); !math.IsNaN(nan) {
+		t.Errorf("expected NaN, got %v", decoded["nan"])
+	}
+	delete(decoded, "nan")
+	delete(properties, "nan")
+	if decoded["loaded_at"].(time.Time).Equal(loadedAt) {
+		decoded["loaded_at"] = loadedAt
+	}
+	if !reflect.DeepEqual(decoded, properties) {
+		t.Errorf("expected %#v, got %#v", properties, decoded)
+	}
+}
+
+func TestEncodeValue_Unsupported(t *testing.T) {
+	if _, err := encodeValue(dbtype.Point2D{X: 1, Y: 2}); err == nil {
+		t.Error("expected an error for an unsupported value type")
+	}
+}
+
+func TestDecodeValue_Invalid(t *testing.T) {
+	for _, value := range []interface{}{
+		json.Number("1.5"),
+		map[string]interface{}{"name": "Brazil"},
+		map[string]interface{}{"$date": "31/01/2021"},
+		map[string]interface{}{"$duration": "P1D"},
+	} {
+		if _, err := decodeValue(value); err == nil {
+			t.Errorf("expected an error decoding %v", value)
+		}
+	}
+}
+
+func writeDump(t *testing.T, manifest Manifest) string {
+	dir := t.TempDir()
+	for _, file := range manifest.Files {
+		if err := os.WriteFile(filepath.Join(dir, file.Name), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, _ := json.Marshal(manifest)
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadManifestAndVerify(t *testing.T) {
+	// SHA-256 of "data"
+	const sum = "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+	manifest := Manifest{
+		Format:        Format,
+		FormatVersion: FormatVersion,
+		Files:         []File{{Name: NodesFile, SHA256: sum}, {Name: RelationshipsFile, SHA256: sum}},
+	}
+
+	dir := writeDump(t, manifest)
+	read, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(dir, read); err != nil {
+		t.Errorf("expected the checksums to match, got %v", err)
+	}
+
+	corrupted := manifest
+	corrupted.Files = []File{{Name: NodesFile, SHA256: sum}, {Name: RelationshipsFile, SHA256: strings.Repeat("0", 64)}}
+	dir = writeDump(t, corrupted)
+	if err := Verify(dir, &corrupted); err == nil || !strings.Contains(err.Error(), RelationshipsFile) {
+		t.Errorf("expected a checksum mismatch for %s, got %v", RelationshipsFile, err)
+	}
+
+	newer := manifest
+	newer.FormatVersion = FormatVersion + 1
+	if _, err := ReadManifest(writeDump(t, newer)); err == nil {
+		t.Error("expected newer format versions to be rejected")
+	}
+
+	incomplete := manifest
+	incomplete.Files = manifest.Files[:1]
+	if _, err := ReadManifest(writeDump(t, incomplete)); err == nil {
+		t.Error("expected a manifest without the relationships file to be rejected")
+	}
+}
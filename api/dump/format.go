@@ -0,0 +1,212 @@
+// Package dump snapshots the whole graph to files and restores it into an
+// empty database, without rerunning the ETL.
+//
+// This file defines the dump format. A dump is a directory holding:
+//
+//   - nodes.ndjson.gz: one JSON object per node, with its labels and properties
+//   - relationships.ndjson.gz: one JSON object per relationship, with its type,
+//     the IDs of its start and end nodes and its properties
+//   - manifest.json: the format version, the schema and dataset versions of
+//     the source database, record counts and the SHA-256 of each file
+//
+// Property values that JSON cannot represent unambiguously are tagged with
+// a single-key object: {"$float": 1.5}, {"$date": "2021-01-31"},
+// {"$datetime": "2021-01-31T12:00:00Z"} and {"$localdatetime": "..."}.
+// Integers, strings, booleans and lists are written as plain JSON.
+
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+const (
+	// Format names the dump format in manifests.
+	Format = "viralgraph-dump"
+
+	// FormatVersion is the version of the format written by Dump. Restore
+	// reads dumps up to this version.
+	FormatVersion = 1
+
+	ManifestFile      = "manifest.json"
+	NodesFile         = "nodes.ndjson.gz"
+	RelationshipsFile = "relationships.ndjson.gz"
+)
+
+const (
+	dateLayout          = "2006-01-02"
+	localDateTimeLayout = "2006-01-02T15:04:05.999999999"
+)
+
+// Manifest describes a dump.
+type Manifest struct {
+	Format            string         `json:"format"`
+	FormatVersion     int            `json:"format_version"`
+	SchemaVersion     int            `json:"schema_version"`
+	DatasetVersion    string         `json:"dataset_version,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	Files             []File         `json:"files"`
+	Labels            map[string]int `json:"labels"`
+	RelationshipTypes map[string]int `json:"relationship_types"`
+}
+
+// File is a data file of the dump, with its number of records and the
+// SHA-256 of its (compressed) content.
+type File struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	SHA256  string `json:"sha256"`
+}
+
+// file returns the manifest entry for the named file.
+func (m *Manifest) file(name string) (File, bool) {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// NodeRecord is a line of the nodes file. IDs are the element IDs of the
+// source database and only serve to link relationships to their nodes.
+type NodeRecord struct {
+	ID         string                 `json:"id"`
+	Labels     []string               `json:"labels"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// RelationshipRecord is a line of the relationships file.
+type RelationshipRecord struct {
+	Type       string                 `json:"type"`
+	Start      string                 `json:"start"`
+	End        string                 `json:"end"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// identifier matches the labels and relationship types that restore
+// accepts, since they are written into the query text.
+var identifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// encodeProperties converts the properties returned by the driver to
+// their JSON form.
+func encodeProperties(properties map[string]interface{}) (map[string]interface{}, error) {
+	encoded := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		v, err := encodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", key, err)
+		}
+		encoded[key] = v
+	}
+	return encoded, nil
+}
+
+func encodeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string, bool, int64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return map[string]interface{}{"$float": strconv.FormatFloat(v, 'g', -1, 64)}, nil
+		}
+		return map[string]interface{}{"$float": v}, nil
+	case dbtype.Date:
+		return map[string]interface{}{"$date": v.Time().Format(dateLayout)}, nil
+	case time.Time:
+		return map[string]interface{}{"$datetime": v.Format(time.RFC3339Nano)}, nil
+	case dbtype.LocalDateTime:
+		return map[string]interface{}{"$localdatetime": v.Time().Format(localDateTimeLayout)}, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			encoded, err := encodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = encoded
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// decodeProperties converts properties read from a dump (decoded with
+// json.Decoder.UseNumber) back to driver values.
+func decodeProperties(properties map[string]interface{}) (map[string]interface{}, error) {
+	decoded := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		v, err := decodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", key, err)
+		}
+		decoded[key] = v
+	}
+	return decoded, nil
+}
+
+func decodeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		return v.Int64()
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			decoded, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = decoded
+		}
+		return list, nil
+	case map[string]interface{}:
+		return decodeTagged(v)
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+func decodeTagged(tagged map[string]interface{}) (interface{}, error) {
+	if len(tagged) != 1 {
+		return nil, errors.New("maps are not valid property values")
+	}
+	for tag, raw := range tagged {
+		if tag == "$float" {
+			switch f := raw.(type) {
+			case json.Number:
+				return f.Float64()
+			case string:
+				return strconv.ParseFloat(f, 64)
+			}
+			return nil, fmt.Errorf("invalid $float %v", raw)
+		}
+
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s %v", tag, raw)
+		}
+		switch tag {
+		case "$date":
+			t, err := time.Parse(dateLayout, s)
+			return dbtype.Date(t), err
+		case "$datetime":
+			return time.Parse(time.RFC3339Nano, s)
+		case "$localdatetime":
+			t, err := time.Parse(localDateTimeLayout, s)
+			return dbtype.LocalDateTime(t), err
+		}
+		return nil, fmt.Errorf("unknown value tag %q", tag)
+	}
+	return nil, nil
+}
@@ -0,0 +1,315 @@
+// Package dump snapshots the whole graph to files and restores it into an
+// empty database, without rerunning the ETL.
+//
+// This file restores dumps. The dump is checked against its manifest
+// before anything is written, the schema is created by the migrations and
+// the nodes and relationships are then created in batches. Nodes carry a
+// temporary label and their dump ID until every relationship is linked.
+
+package dump
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/migrations"
+	"github.com/biiafranca/viralgraph/api/neo4j"
+)
+
+const (
+	batchSize = 1000
+
+	// restoreLabel and restoreProperty mark restored nodes until their
+	// relationships are linked.
+	restoreLabel    = "ViralgraphRestore"
+	restoreProperty = "restore_id"
+	restoreIndex    = "viralgraph_restore"
+)
+
+// Restore loads the dump in dir into the database, which must be empty
+// (apart from applied schema migrations).
+func Restore(ctx context.Context, dir string) (*Manifest, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(dir, manifest); err != nil {
+		return nil, err
+	}
+
+	nodes, relationships, err := count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if nodes > 0 {
+		return nil, fmt.Errorf("the database is not empty (%d nodes); restore only loads into an empty database", nodes)
+	}
+
+	if _, err := migrations.Apply(ctx); err != nil {
+		return nil, fmt.Errorf("applying schema migrations: %w", err)
+	}
+	err = neo4j.Write(ctx, "restore.index", fmt.Sprintf(
+		"CREATE INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s)", restoreIndex, restoreLabel, restoreProperty,
+	), nil)
+	if err == nil {
+		err = neo4j.Write(ctx, "restore.await_index", "CALL db.awaitIndexes(300)", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := readFile(dir, manifest, NodesFile, restoreNodes(ctx)); err != nil {
+		return nil, err
+	}
+	if err := readFile(dir, manifest, RelationshipsFile, restoreRelationships(ctx)); err != nil {
+		return nil, err
+	}
+
+	err = neo4j.Write(ctx, "restore.cleanup", fmt.Sprintf(`
+		MATCH (n:%[1]s)
+		CALL { WITH n REMOVE n:%[1]s REMOVE n.%[2]s } IN TRANSACTIONS OF 10000 ROWS
+	`, restoreLabel, restoreProperty), nil)
+	if err == nil {
+		err = neo4j.Write(ctx, "restore.drop_index", fmt.Sprintf("DROP INDEX %s IF EXISTS", restoreIndex), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, relationships, err = count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expectedNodes, _ := manifest.file(NodesFile)
+	expectedRelationships, _ := manifest.file(RelationshipsFile)
+	if nodes != expectedNodes.Records || relationships != expectedRelationships.Records {
+		return nil, fmt.Errorf("restored %d nodes and %d relationships, expected %d and %d",
+			nodes, relationships, expectedNodes.Records, expectedRelationships.Records)
+	}
+	return manifest, nil
+}
+
+// ReadManifest reads and checks the manifest of the dump in dir.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	switch {
+	case manifest.Format != Format:
+		return nil, fmt.Errorf("not a %s manifest", Format)
+	case manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion:
+		return nil, fmt.Errorf("unsupported dump format version %d (this version reads up to %d)", manifest.FormatVersion, FormatVersion)
+	case manifest.SchemaVersion > migrations.Latest():
+		return nil, fmt.Errorf("the dump has schema version %d, newer than the latest known migration (%d)", manifest.SchemaVersion, migrations.Latest())
+	}
+	for _, name := range []string{NodesFile, RelationshipsFile} {
+		if _, ok := manifest.file(name); !ok {
+			return nil, fmt.Errorf("the manifest lists no %s", name)
+		}
+	}
+	return &manifest, nil
+}
+
+// Verify checks that every file listed in the manifest has the recorded
+// checksum.
+func Verify(dir string, manifest *Manifest) error {
+	for _, file := range manifest.Files {
+		f, err := os.Open(filepath.Join(dir, file.Name))
+		if err != nil {
+			return err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if sum := hex.EncodeToString(hash.Sum(nil)); sum != file.SHA256 {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", file.Name, file.SHA256, sum)
+		}
+	}
+	return nil
+}
+
+// count returns the number of nodes, apart from schema migrations, and
+// relationships in the database.
+func count(ctx context.Context) (int, int, error) {
+	records, err := neo4j.Query(ctx, "restore.count", `
+		CALL { MATCH (n) WHERE NOT n:SchemaMigration RETURN count(n) AS nodes }
+		CALL { MATCH ()-[r]->() RETURN count(r) AS relationships }
+		RETURN nodes, relationships
+	`, nil)
+	if err != nil || len(records) == 0 {
+		return 0, 0, err
+	}
+	nodes, _ := records[0].Get("nodes")
+	relationships, _ := records[0].Get("relationships")
+	return int(nodes.(int64)), int(relationships.(int64)), nil
+}
+
+// readFile passes each record of a data file to the batcher, then checks
+// that the file held as many records as the manifest says.
+func readFile(dir string, manifest *Manifest, name string, restorer *batcher) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	dec := json.NewDecoder(gz)
+	dec.UseNumber()
+	records := 0
+	for {
+		err := restorer.add(dec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s, record %d: %w", name, records+1, err)
+		}
+		records++
+	}
+	if err := restorer.flush(); err != nil {
+		return err
+	}
+
+	if expected, _ := manifest.file(name); records != expected.Records {
+		return fmt.Errorf("%s holds %d records, the manifest lists %d", name, records, expected.Records)
+	}
+	slog.Info("Restored records", "file", name, "records", records)
+	return nil
+}
+
+// batcher groups the decoded records by the query that creates them
+// (one per label set or relationship type) and runs each batch when full.
+type batcher struct {
+	ctx     context.Context
+	name    string
+	decode  func(dec *json.Decoder) (group string, row map[string]interface{}, err error)
+	query   func(group string) string
+	batches map[string][]map[string]interface{}
+}
+
+func (b *batcher) add(dec *json.Decoder) error {
+	group, row, err := b.decode(dec)
+	if err != nil {
+		return err
+	}
+	b.batches[group] = append(b.batches[group], row)
+	if len(b.batches[group]) < batchSize {
+		return nil
+	}
+	return b.run(group)
+}
+
+// flush runs the remaining batches, in a stable order.
+func (b *batcher) flush() error {
+	groups := make([]string, 0, len(b.batches))
+	for group := range b.batches {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if err := b.run(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *batcher) run(group string) error {
+	rows := b.batches[group]
+	delete(b.batches, group)
+	if len(rows) == 0 {
+		return nil
+	}
+	return neo4j.Write(b.ctx, b.name, b.query(group), map[string]interface{}{"rows": rows})
+}
+
+func restoreNodes(ctx context.Context) *batcher {
+	return &batcher{
+		ctx:  ctx,
+		name: "restore.nodes",
+		decode: func(dec *json.Decoder) (string, map[string]interface{}, error) {
+			var node NodeRecord
+			if err := dec.Decode(&node); err != nil {
+				return "", nil, err
+			}
+			for _, label := range node.Labels {
+				if !identifier.MatchString(label) {
+					return "", nil, fmt.Errorf("invalid label %q", label)
+				}
+			}
+			properties, err := decodeProperties(node.Properties)
+			if err != nil {
+				return "", nil, err
+			}
+			labels := append(append([]string{}, node.Labels...), restoreLabel)
+			return strings.Join(labels, ":"), map[string]interface{}{"id": node.ID, "properties": properties}, nil
+		},
+		query: func(labels string) string {
+			return fmt.Sprintf(`
+				UNWIND $rows AS row
+				CREATE (n:%s {%s: row.id})
+				SET n += row.properties
+			`, labels, restoreProperty)
+		},
+		batches: map[string][]map[string]interface{}{},
+	}
+}
+
+func restoreRelationships(ctx context.Context) *batcher {
+	return &batcher{
+		ctx:  ctx,
+		name: "restore.relationships",
+		decode: func(dec *json.Decoder) (string, map[string]interface{}, error) {
+			var relationship RelationshipRecord
+			if err := dec.Decode(&relationship); err != nil {
+				return "", nil, err
+			}
+			if !identifier.MatchString(relationship.Type) {
+				return "", nil, fmt.Errorf("invalid relationship type %q", relationship.Type)
+			}
+			properties, err := decodeProperties(relationship.Properties)
+			if err != nil {
+				return "", nil, err
+			}
+			return relationship.Type, map[string]interface{}{
+				"start":      relationship.Start,
+				"end":        relationship.End,
+				"properties": properties,
+			}, nil
+		},
+		query: func(kind string) string {
+			return fmt.Sprintf(`
+				UNWIND $rows AS row
+				MATCH (a:%[1]s {%[2]s: row.start})
+				MATCH (b:%[1]s {%[2]s: row.end})
+				CREATE (a)-[r:%[3]s]->(b)
+				SET r += row.properties
+			`, restoreLabel, restoreProperty, kind)
+		},
+		batches: map[string][]map[string]interface{}{},
+	}
+}
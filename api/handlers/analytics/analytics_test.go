@@ -0,0 +1,108 @@
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/biiafranca/viralgraph/api/analytics"
+)
+
+func TestHandleCommunities_InvalidParams(t *testing.T) {
+	for _, path := range []string{
+		"/analytics/communities?projection=unknown",
+		"/analytics/communities?algorithm=pagerank",
+		"/analytics/communities?min-similarity=2",
+		// Writing back is only done by POST
+		"/analytics/communities?write=true",
+	} {
+		rec := httptest.NewRecorder()
+		HandleCommunities(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestHandleCentrality_InvalidParams(t *testing.T) {
+	for _, path := range []string{
+		"/analytics/centrality?algorithm=louvain",
+		"/analytics/centrality?kind=region",
+		"/analytics/centrality?limit=0",
+	} {
+		rec := httptest.NewRecorder()
+		HandleCentrality(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestProjectionSpec_Enum(t *testing.T) {
+	// The projection parameter must accept exactly the available projections
+	field, _ := reflect.TypeOf(projectionSpec{}).FieldByName("Name")
+	if enum := field.Tag.Get("enum"); enum != strings.Join(analytics.Projections, ",") {
+		t.Errorf("expected the projections %v, got %s", analytics.Projections, enum)
+	}
+}
+
+func TestCommunities(t *testing.T) {
+	g := analytics.NewGraph()
+	for _, key := range []string{"ARG", "BRA", "CHN", "IND", "RUS"} {
+		g.AddNode(analytics.Node{Kind: analytics.CountryKind, Key: key})
+	}
+	result, ids := communities(g, []int{3, 3, 5, 5, 5})
+	if len(result) != 2 || result[0].Size != 3 || result[0].Members[0].Key != "CHN" || result[1].Members[1].Key != "BRA" {
+		t.Errorf("expected the larger community first, got %+v", result)
+	}
+	if ids[5] != 0 || ids[3] != 1 {
+		t.Errorf("expected communities 5 and 3 to be renumbered 0 and 1, got %v", ids)
+	}
+}
+
+func TestHandleCommunities_Positive(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleCommunities(rec, httptest.NewRequest(http.MethodGet, "/analytics/communities?projection=country-similarity&min-similarity=0.5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response CommunitiesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	total := 0
+	for _, c := range response.Communities {
+		total += c.Size
+	}
+	if total != response.Nodes || response.Modularity == nil {
+		t.Errorf("expected every node in a community and a modularity, got %+v", response)
+	}
+}
+
+func TestHandleCentrality_Positive(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleCentrality(rec, httptest.NewRequest(http.MethodGet, "/analytics/centrality?kind=vaccine&limit=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response CentralityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Entries) == 0 || len(response.Entries) > 5 {
+		t.Fatalf("expected between 1 and 5 entries, got %d", len(response.Entries))
+	}
+	for i, entry := range response.Entries {
+		if entry.Kind != analytics.VaccineKind {
+			t.Errorf("expected only vaccines, got %+v", entry)
+		}
+		if i > 0 && entry.Value > response.Entries[i-1].Value {
+			t.Errorf("expected descending values, got %v after %v", entry.Value, response.Entries[i-1].Value)
+		}
+	}
+}
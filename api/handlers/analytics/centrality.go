@@ -0,0 +1,96 @@
+// Package analytics serves the results of the graph algorithms of the
+// analytics package over projections of the loaded graph.
+//
+// Returns the nodes of a projection ranked by a centrality measure.
+// POST also writes the measure back to the graph.
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/biiafranca/viralgraph/api/analytics"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+var centralities = map[string]func(*analytics.Graph) []float64{
+	"pagerank":    analytics.PageRank,
+	"degree":      analytics.Degree,
+	"strength":    analytics.Strength,
+	"closeness":   analytics.Closeness,
+	"betweenness": analytics.Betweenness,
+}
+
+type centralityParams struct {
+	projectionSpec
+	Algorithm string `query:"algorithm" default:"pagerank" enum:"pagerank,degree,strength,closeness,betweenness"`
+	Kind      string `query:"kind" enum:"country,vaccine"`
+	Limit     int    `query:"limit" default:"20" min:"1" max:"500"`
+}
+
+func HandleCentrality(w http.ResponseWriter, r *http.Request) {
+	var p centralityParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	spec, algorithm, kind, limit := p.projectionSpec, p.Algorithm, p.Kind, p.Limit
+	measure := centralities[algorithm]
+
+	g, err := spec.load(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	values := measure(g)
+
+	response := CentralityResponse{
+		Projection: spec.Name,
+		Algorithm:  algorithm,
+		Nodes:      g.Len(),
+		Edges:      g.EdgeCount(),
+		Entries:    ranking(g, values, kind, limit),
+	}
+
+	if writes(r) {
+		written := make([]interface{}, len(values))
+		for i, value := range values {
+			written[i] = value
+		}
+		property := spec.property(algorithm)
+		if err := writeBack(r.Context(), g, property, written); err != nil {
+			utils.RespondWithError(w, utils.NewProblem(utils.CodeDatabaseError, fmt.Sprintf("Failed to write %s to the database", property)))
+			return
+		}
+		response.WrittenTo = property
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// ranking returns the top nodes of the kind (any kind when empty) by
+// decreasing value, ties by key.
+func ranking(g *analytics.Graph, values []float64, kind string, limit int) []CentralityEntry {
+	entries := []CentralityEntry{}
+	for i, node := range g.Nodes {
+		if kind == "" || node.Kind == kind {
+			entries = append(entries, CentralityEntry{Kind: node.Kind, Key: node.Key, Name: node.Name, Value: values[i]})
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Value != entries[b].Value {
+			return entries[a].Value > entries[b].Value
+		}
+		return entries[a].Key < entries[b].Key
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries
+}
@@ -0,0 +1,114 @@
+// Package analytics serves the results of the graph algorithms of the
+// analytics package over projections of the loaded graph.
+//
+// Returns the communities (Louvain) or connected components of a
+// projection, largest first. POST also writes them back to the graph.
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/biiafranca/viralgraph/api/analytics"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+type communitiesParams struct {
+	projectionSpec
+	Algorithm string `query:"algorithm" default:"louvain" enum:"louvain,components"`
+}
+
+func HandleCommunities(w http.ResponseWriter, r *http.Request) {
+	var p communitiesParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	spec, algorithm := p.projectionSpec, p.Algorithm
+
+	g, err := spec.load(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+
+	response := CommunitiesResponse{
+		Projection: spec.Name,
+		Algorithm:  algorithm,
+		Nodes:      g.Len(),
+		Edges:      g.EdgeCount(),
+	}
+	var assignment []int
+	if algorithm == "louvain" {
+		var q float64
+		assignment, q = analytics.Louvain(g)
+		response.Modularity = &q
+	} else {
+		assignment = analytics.ConnectedComponents(g)
+	}
+	var ids map[int]int
+	response.Communities, ids = communities(g, assignment)
+
+	if writes(r) {
+		// Written IDs match the response, which numbers communities by size.
+		values := make([]interface{}, g.Len())
+		for i, c := range assignment {
+			values[i] = ids[c]
+		}
+		property := spec.property(algorithm)
+		if err := writeBack(r.Context(), g, property, values); err != nil {
+			utils.RespondWithError(w, utils.NewProblem(utils.CodeDatabaseError, fmt.Sprintf("Failed to write %s to the database", property)))
+			return
+		}
+		response.WrittenTo = property
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// communities groups the nodes by community, numbering the communities by
+// decreasing size (ties by their first node). It also returns the new ID of
+// each community of the assignment.
+func communities(g *analytics.Graph, assignment []int) ([]Community, map[int]int) {
+	byID := map[int]*Community{}
+	var list []*Community
+	var order []int
+	for i, c := range assignment {
+		community, ok := byID[c]
+		if !ok {
+			community = &Community{Members: []Member{}}
+			byID[c] = community
+			list = append(list, community)
+			order = append(order, c)
+		}
+		node := g.Nodes[i]
+		community.Members = append(community.Members, Member{Kind: node.Kind, Key: node.Key, Name: node.Name})
+		community.Size++
+	}
+
+	positions := make([]int, len(list))
+	for i := range positions {
+		positions[i] = i
+	}
+	sort.SliceStable(positions, func(a, b int) bool { return list[positions[a]].Size > list[positions[b]].Size })
+
+	result := make([]Community, 0, len(list))
+	ids := make(map[int]int, len(list))
+	for id, position := range positions {
+		community := list[position]
+		community.ID = id
+		ids[order[position]] = id
+		sort.Slice(community.Members, func(a, b int) bool {
+			x, y := community.Members[a], community.Members[b]
+			if x.Kind != y.Kind {
+				return x.Kind < y.Kind
+			}
+			return x.Key < y.Key
+		})
+		result = append(result, *community)
+	}
+	return result, ids
+}
@@ -0,0 +1,102 @@
+// Package analytics serves the results of the graph algorithms of the
+// analytics package over projections of the loaded graph.
+//
+// This file declares the projection parameters, loads the USES relationships
+// the projections are built from, and writes results back as node
+// properties.
+
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/analytics"
+	"github.com/biiafranca/viralgraph/api/neo4j"
+)
+
+// projectionSpec holds the query parameters shared by analytics endpoints:
+// the projection and the minimum similarity of its edges.
+type projectionSpec struct {
+	Name          string  `query:"projection" default:"uses" enum:"uses,country-similarity,vaccine-similarity"`
+	MinSimilarity float64 `query:"min-similarity" min:"0" max:"1"`
+}
+
+// writes reports whether the request writes results back to the graph,
+// which only POST requests do: GET requests stay safe, and may be answered
+// from the response cache without running the handler.
+func writes(r *http.Request) bool {
+	return r.Method == http.MethodPost
+}
+
+// load reads the USES relationships and builds the projection.
+func (spec projectionSpec) load(ctx context.Context) (*analytics.Graph, error) {
+	query := `
+		MATCH (c:Country)-[:USES]->(v:Vaccine)
+		RETURN c.iso3 AS country, c.name AS country_name,
+			coalesce(v.slug, v.name) AS vaccine, v.name AS vaccine_name
+		ORDER BY country, vaccine
+	`
+	records, err := neo4j.Query(ctx, "analytics.uses", query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	uses := make([]analytics.Use, 0, len(records))
+	for _, record := range records {
+		country, _ := record.Get("country")
+		countryName, _ := record.Get("country_name")
+		vaccine, _ := record.Get("vaccine")
+		vaccineName, _ := record.Get("vaccine_name")
+
+		use := analytics.Use{
+			Country: analytics.Node{Kind: analytics.CountryKind},
+			Vaccine: analytics.Node{Kind: analytics.VaccineKind},
+		}
+		use.Country.Key, _ = country.(string)
+		use.Country.Name, _ = countryName.(string)
+		use.Vaccine.Key, _ = vaccine.(string)
+		use.Vaccine.Name, _ = vaccineName.(string)
+		uses = append(uses, use)
+	}
+
+	g, _ := analytics.Project(spec.Name, uses, spec.MinSimilarity)
+	return g, nil
+}
+
+// property names the node property results are written to, e.g.
+// "louvain_country_similarity". Both parts come from fixed lists, so the
+// name is safe to write into the query text.
+func (spec projectionSpec) property(algorithm string) string {
+	return strings.ReplaceAll(algorithm+"_"+spec.Name, "-", "_")
+}
+
+// writeBack stores each node's value in the property, on Country nodes
+// (by ISO3 code) and Vaccine nodes (by slug).
+func writeBack(ctx context.Context, g *analytics.Graph, property string, values []interface{}) error {
+	rows := map[string][]map[string]interface{}{}
+	for i, node := range g.Nodes {
+		rows[node.Kind] = append(rows[node.Kind], map[string]interface{}{"key": node.Key, "value": values[i]})
+	}
+
+	matches := map[string]string{
+		analytics.CountryKind: "MATCH (n:Country {iso3: row.key})",
+		analytics.VaccineKind: "MATCH (n:Vaccine {slug: row.key})",
+	}
+	for _, kind := range []string{analytics.CountryKind, analytics.VaccineKind} {
+		if len(rows[kind]) == 0 {
+			continue
+		}
+		query := fmt.Sprintf(`
+			UNWIND $rows AS row
+			%s
+			SET n.%s = row.value
+		`, matches[kind], property)
+		if err := neo4j.Write(ctx, "analytics.write."+kind, query, map[string]interface{}{"rows": rows[kind]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package analytics serves the results of the graph algorithms of the
+// analytics package over projections of the loaded graph.
+// Defines response data structures used across analytics handlers.
+
+package analytics
+
+type Member struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type Community struct {
+	ID      int      `json:"id"`
+	Size    int      `json:"size"`
+	Members []Member `json:"members"`
+}
+
+type CommunitiesResponse struct {
+	Projection  string      `json:"projection"`
+	Algorithm   string      `json:"algorithm"`
+	Nodes       int         `json:"nodes"`
+	Edges       int         `json:"edges"`
+	Modularity  *float64    `json:"modularity,omitempty"`
+	Communities []Community `json:"communities" table:"rows"`
+	WrittenTo   string      `json:"written_to,omitempty"`
+}
+
+type CentralityEntry struct {
+	Rank  int     `json:"rank"`
+	Kind  string  `json:"kind"`
+	Key   string  `json:"key"`
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+type CentralityResponse struct {
+	Projection string            `json:"projection"`
+	Algorithm  string            `json:"algorithm"`
+	Nodes      int               `json:"nodes"`
+	Edges      int               `json:"edges"`
+	Entries    []CentralityEntry `json:"entries" table:"rows"`
+	WrittenTo  string            `json:"written_to,omitempty"`
+}
@@ -0,0 +1,176 @@
+// Package batch handles batch lookups of COVID-19 statistics.
+// This file decodes and validates batch requests, and executes them
+// with a few UNWIND queries instead of one query per item.
+
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+const (
+	// maxItems bounds the number of items accepted in one request.
+	maxItems = 5000
+
+	// chunkSize is the number of items sent in each UNWIND query.
+	chunkSize = 1000
+
+	// maxBodyBytes bounds the size of the request body.
+	maxBodyBytes = 1 << 20
+)
+
+// queries holds the UNWIND queries of one kind of statistic. Both receive
+// $items, a list of {index, country, date} maps, and return an index column
+// and a column per metric.
+type queries struct {
+	name        string
+	accumulated string
+	new         string
+}
+
+// metricQueries builds the queries of the metrics from the registry, so
+// that batch lookups match the single ones (see stats.ValueQuery).
+func metricQueries(name string, ms ...registry.Metric) queries {
+	return queries{
+		name:        name,
+		accumulated: stats.BatchQuery(ms, false),
+		new:         stats.BatchQuery(ms, true),
+	}
+}
+
+// lookup holds the outcome of a batch: a record or a problem per item index.
+type lookup struct {
+	records map[int]*neo4j.Record
+	errors  map[int]*utils.Problem
+}
+
+// decodeItems reads the JSON array of request items from the body.
+func decodeItems(w http.ResponseWriter, r *http.Request) ([]RequestItem, error) {
+	var items []RequestItem
+	body := http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(body).Decode(&items); err != nil {
+		return nil, utils.NewProblem(utils.CodeInvalidBody, "Invalid request body. Send a JSON array of {country, date, only_news} items.")
+	}
+	if len(items) == 0 {
+		return nil, utils.NewProblem(utils.CodeInvalidBody, "The batch must contain at least one item")
+	}
+	if len(items) > maxItems {
+		return nil, utils.NewProblem(utils.CodeInvalidBody, fmt.Sprintf("The batch must contain at most %d items", maxItems))
+	}
+	return items, nil
+}
+
+// group holds the items sent to one query.
+type group struct {
+	name, query string
+	rows        []map[string]interface{}
+}
+
+// execute validates each item and runs the valid ones in chunks, grouped
+// by the queries pick chooses for them (which may reject the item) and
+// split between accumulated and only-news queries. A failed chunk only
+// marks its own items as failed.
+func execute(ctx context.Context, items []RequestItem, pick func(RequestItem) (queries, error)) lookup {
+	result := lookup{records: map[int]*neo4j.Record{}, errors: map[int]*utils.Problem{}}
+	var groups []*group
+	byName := map[string]*group{}
+
+	for i, item := range items {
+		if strings.TrimSpace(item.Country) == "" {
+			result.errors[i] = utils.MissingParam("country", "Country is required")
+			continue
+		}
+		country, err := param.ParseCountry("country", item.Country)
+		if err != nil {
+			result.errors[i] = utils.AsProblem(err)
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", item.Date); err != nil {
+			result.errors[i] = utils.InvalidParam("date", "Invalid date format. Use YYYY-MM-DD.")
+			continue
+		}
+		if err := param.NotFuture("date", param.Date(item.Date)); err != nil {
+			result.errors[i] = utils.AsProblem(err)
+			continue
+		}
+
+		q, err := pick(item)
+		if err != nil {
+			result.errors[i] = utils.AsProblem(err)
+			continue
+		}
+
+		name, query := q.name+".accumulated", q.accumulated
+		if item.OnlyNews {
+			name, query = q.name+".new", q.new
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &group{name: name, query: query}
+			byName[name] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, map[string]interface{}{"index": i, "country": string(country), "date": item.Date})
+	}
+
+	run := func(name, query string, params []map[string]interface{}) {
+		for start := 0; start < len(params); start += chunkSize {
+			chunk := params[start:min(start+chunkSize, len(params))]
+			records, err := neo4j.Query(ctx, name, query, map[string]interface{}{"items": chunk})
+			if err != nil {
+				for _, row := range chunk {
+					result.errors[row["index"].(int)] = utils.DatabaseError()
+				}
+				continue
+			}
+			for _, record := range records {
+				index, _ := record.Get("index")
+				result.records[int(index.(int64))] = record
+			}
+		}
+	}
+	for _, g := range groups {
+		run(g.name, g.query, g.rows)
+	}
+
+	// Items without a record either name an unknown country or have no data.
+	var missing []int
+	var countries []string
+	for i, item := range items {
+		if _, ok := result.records[i]; !ok && result.errors[i] == nil {
+			missing = append(missing, i)
+			countries = append(countries, label(item))
+		}
+	}
+	if len(missing) == 0 {
+		return result
+	}
+	known, err := neo4j.KnownCountries(ctx, countries)
+	for j, i := range missing {
+		switch {
+		case err != nil:
+			result.errors[i] = utils.DatabaseError()
+		case !known[countries[j]]:
+			result.errors[i] = utils.UnknownCountry(countries[j])
+		default:
+			result.errors[i] = utils.NewProblem(utils.CodeNoData, "No data found for the given input")
+		}
+	}
+	return result
+}
+
+// label returns the normalised country code reported in results.
+func label(item RequestItem) string {
+	return strings.ToUpper(strings.TrimSpace(item.Country))
+}
@@ -0,0 +1,111 @@
+package batch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHandleCovidStats_InvalidBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch/covid-stats", strings.NewReader(`{"country": "BRA"}`))
+	rec := httptest.NewRecorder()
+	HandleCovidStats(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleVaccination_EmptyBatch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch/vaccination", strings.NewReader(`[]`))
+	rec := httptest.NewRecorder()
+	HandleVaccination(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleCovidStats_Route_PerItemErrors(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/batch/covid-stats", HandleCovidStats)
+
+	// Real ISO3 code and dates present in the test database, plus invalid items
+	body := `[
+		{"country": "BRA", "date": "2021-07-31"},
+		{"country": "bra", "date": "2021-07-31", "only_news": true},
+		{"country": "BRA", "date": "bad-date"},
+		{"country": "XYZ", "date": "2021-07-31"}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/batch/covid-stats", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response CovidStatsBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(response.Results))
+	}
+	for i, wantError := range []bool{false, false, true, true} {
+		if got := response.Results[i].Error != ""; got != wantError {
+			t.Errorf("item %d: expected error=%v, got %q", i, wantError, response.Results[i].Error)
+		}
+	}
+	for i, wantCode := range map[int]string{2: "invalid-parameter", 3: "unknown-country"} {
+		if got := response.Results[i].Code; got != wantCode {
+			t.Errorf("item %d: expected code %q, got %q", i, wantCode, got)
+		}
+	}
+}
+
+func TestHandleVaccination_Route_Positive(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/batch/vaccination", HandleVaccination)
+
+	body := `[{"country": "BRA", "date": "2021-07-31"}, {"country": "BRA", "date": "2021-07-31", "only_news": true}, {"country": "BRA", "date": "2021-07-31", "dose": "full"}]`
+	req := httptest.NewRequest(http.MethodPost, "/batch/vaccination", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response VaccinationBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, result := range response.Results {
+		want := "first"
+		if result.Index == 2 {
+			want = "full"
+		}
+		if result.Error == "" && result.Dose != want {
+			t.Errorf("item %d: expected the %s dose series, got %q", result.Index, want, result.Dose)
+		}
+	}
+}
+
+func TestHandleVaccination_InvalidDose(t *testing.T) {
+	body := `[{"country": "BRA", "date": "2021-07-31", "dose": "second"}]`
+	req := httptest.NewRequest(http.MethodPost, "/batch/vaccination", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	HandleVaccination(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response VaccinationBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got := response.Results[0].Code; got != "invalid-parameter" {
+		t.Errorf("expected code %q, got %q", "invalid-parameter", got)
+	}
+}
@@ -0,0 +1,56 @@
+// Package batch handles batch lookups of COVID-19 statistics.
+//
+// Returns accumulated or new cases and deaths for many (country, date) pairs.
+
+package batch
+
+import (
+	"net/http"
+
+	"github.com/biiafranca/viralgraph/api/handlers/covidstats"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+var covidStatsQueries = metricQueries("batch.covidstats",
+	registry.MustLookup("cases"),
+	registry.MustLookup("deaths"),
+)
+
+func HandleCovidStats(w http.ResponseWriter, r *http.Request) {
+	items, err := decodeItems(w, r)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	found := execute(r.Context(), items, func(RequestItem) (queries, error) {
+		return covidStatsQueries, nil
+	})
+
+	results := make([]CovidStatsResult, len(items))
+	for i, item := range items {
+		results[i].Index = i
+		if problem, failed := found.errors[i]; failed {
+			results[i].Error, results[i].Code = problem.Detail, problem.Code
+			continue
+		}
+
+		record := found.records[i]
+		cases, _ := record.Get("cases")
+		deaths, _ := record.Get("deaths")
+		results[i].CovidStatsResponse = &covidstats.CovidStatsResponse{
+			Country:  label(item),
+			Date:     item.Date,
+			OnlyNews: item.OnlyNews,
+		}
+		results[i].Cases, _ = cases.(int64)
+		results[i].Deaths, _ = deaths.(int64)
+	}
+
+	response := CovidStatsBatchResponse{
+		Results: results,
+	}
+
+	utils.Respond(w, r, response)
+}
@@ -0,0 +1,43 @@
+// Package batch handles batch lookups of COVID-19 statistics.
+// Defines request and response data structures used across batch handlers.
+//
+// Each result carries the index of the request item it answers, and either
+// the values or an error message with its problem code (see utils.Problem),
+// so one bad item does not fail the batch.
+
+package batch
+
+import (
+	"github.com/biiafranca/viralgraph/api/handlers/covidstats"
+	"github.com/biiafranca/viralgraph/api/handlers/vaccination"
+)
+
+type RequestItem struct {
+	Country  string `json:"country"`
+	Date     string `json:"date"`
+	OnlyNews bool   `json:"only_news"`
+	// Dose selects the dose series of vaccination items (first by default).
+	Dose string `json:"dose,omitempty"`
+}
+
+type CovidStatsResult struct {
+	Index int    `json:"index"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+	*covidstats.CovidStatsResponse
+}
+
+type CovidStatsBatchResponse struct {
+	Results []CovidStatsResult `json:"results" table:"rows"`
+}
+
+type VaccinationResult struct {
+	Index int    `json:"index"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+	*vaccination.VaccinationResponse
+}
+
+type VaccinationBatchResponse struct {
+	Results []VaccinationResult `json:"results" table:"rows"`
+}
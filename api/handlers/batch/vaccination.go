@@ -0,0 +1,75 @@
+// Package batch handles batch lookups of COVID-19 statistics.
+//
+// Returns accumulated or new vaccinations of a dose series for many
+// (country, date) pairs.
+
+package batch
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/handlers/vaccination"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// vaccinationQueries holds the queries of each dose series, matching the
+// `dose` parameter of the vaccination handlers.
+var vaccinationQueries = func() map[string]queries {
+	qs := map[string]queries{}
+	for dose, m := range vaccination.Doses {
+		qs[dose] = metricQueries("batch.vaccination."+dose, m)
+	}
+	return qs
+}()
+
+// doseOf returns the dose series of an item, first by default.
+func doseOf(item RequestItem) (string, error) {
+	if strings.TrimSpace(item.Dose) == "" {
+		return "first", nil
+	}
+	return param.ParseEnum("dose", item.Dose, vaccination.DoseValues)
+}
+
+func HandleVaccination(w http.ResponseWriter, r *http.Request) {
+	items, err := decodeItems(w, r)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	found := execute(r.Context(), items, func(item RequestItem) (queries, error) {
+		dose, err := doseOf(item)
+		if err != nil {
+			return queries{}, err
+		}
+		return vaccinationQueries[dose], nil
+	})
+
+	results := make([]VaccinationResult, len(items))
+	for i, item := range items {
+		results[i].Index = i
+		if problem, failed := found.errors[i]; failed {
+			results[i].Error, results[i].Code = problem.Detail, problem.Code
+			continue
+		}
+
+		dose, _ := doseOf(item)
+		record := found.records[i]
+		vaccinated, _ := record.Get(vaccination.Doses[dose].Name)
+		results[i].VaccinationResponse = &vaccination.VaccinationResponse{
+			Country:  label(item),
+			Date:     item.Date,
+			OnlyNews: item.OnlyNews,
+			Dose:     dose,
+		}
+		results[i].TotalVaccinated, _ = vaccinated.(int64)
+	}
+
+	response := VaccinationBatchResponse{
+		Results: results,
+	}
+
+	utils.Respond(w, r, response)
+}
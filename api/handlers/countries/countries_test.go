@@ -0,0 +1,63 @@
+package countries
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/countries/{country}/similar-portfolio", HandleSimilarPortfolio)
+	return r
+}
+
+func TestHandleSimilarPortfolio_InvalidLimit(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/countries/BRA/similar-portfolio?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleSimilarPortfolio_Positive(t *testing.T) {
+	// Real ISO3 code present in the test database
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/countries/bra/similar-portfolio?limit=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response SimilarPortfolioResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Country != "BRA" || len(response.Portfolio) == 0 {
+		t.Errorf("expected BRA's portfolio, got %+v", response)
+	}
+	if len(response.Entries) == 0 || len(response.Entries) > 5 {
+		t.Errorf("expected between 1 and 5 entries, got %d", len(response.Entries))
+	}
+	for i, entry := range response.Entries {
+		if entry.Country == "BRA" {
+			t.Error("expected the country itself to be excluded")
+		}
+		if entry.Jaccard <= 0 || entry.Jaccard > 1 {
+			t.Errorf("expected a Jaccard score in (0, 1], got %v", entry.Jaccard)
+		}
+		if i > 0 && entry.Jaccard > response.Entries[i-1].Jaccard {
+			t.Errorf("expected descending scores, got %v after %v", entry.Jaccard, response.Entries[i-1].Jaccard)
+		}
+	}
+}
+
+func TestHandleSimilarPortfolio_NonexistentCountry(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/countries/XXX/similar-portfolio", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
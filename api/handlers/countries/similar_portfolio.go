@@ -0,0 +1,116 @@
+// Package countries handles country-centred views of the graph.
+//
+// Returns the countries whose vaccine portfolios (the vaccines they use,
+// through USES relationships) are most similar to the given country's,
+// scored by the Jaccard similarity of the two sets of vaccines.
+
+package countries
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+type similarPortfolioParams struct {
+	Country param.Country `path:"country" required:"true"`
+	Limit   int           `query:"limit" default:"10" min:"1" max:"100"`
+}
+
+func HandleSimilarPortfolio(w http.ResponseWriter, r *http.Request) {
+	var p similarPortfolioParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	country, limit := string(p.Country), p.Limit
+
+	// Countries sharing at least one vaccine, with the shared vaccines and
+	// the size of their own portfolio.
+	query := `
+		MATCH (c:Country {iso3: $country})
+		CALL {
+			WITH c
+			OPTIONAL MATCH (c)-[:USES]->(v:Vaccine)
+			WITH v ORDER BY v.name
+			RETURN collect(v.name) AS portfolio
+		}
+		OPTIONAL MATCH (c)-[:USES]->(v:Vaccine)<-[:USES]-(other:Country)
+		WITH portfolio, other, v ORDER BY v.name
+		WITH portfolio, other, collect(v.name) AS shared
+		RETURN portfolio, other.iso3 AS country, other.name AS name, shared,
+			COUNT { (other)-[:USES]->(:Vaccine) } AS vaccines
+	`
+	params := map[string]interface{}{"country": country}
+
+	records, err := neo4j.Query(r.Context(), "countries.similar_portfolio", query, params)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	if len(records) == 0 {
+		utils.RespondWithError(w, utils.UnknownCountry(country))
+		return
+	}
+
+	portfolio, _ := records[0].Get("portfolio")
+	response := SimilarPortfolioResponse{
+		Country:   country,
+		Portfolio: stringList(portfolio),
+		Entries:   []SimilarCountry{},
+	}
+
+	for _, record := range records {
+		other, _ := record.Get("country")
+		otherStr, ok := other.(string)
+		if !ok {
+			// A country sharing no vaccine with any other
+			continue
+		}
+		name, _ := record.Get("name")
+		shared, _ := record.Get("shared")
+		vaccines, _ := record.Get("vaccines")
+		vaccinesInt, _ := vaccines.(int64)
+
+		entry := SimilarCountry{
+			Country:        otherStr,
+			SharedVaccines: stringList(shared),
+			Vaccines:       int(vaccinesInt),
+		}
+		entry.Name, _ = name.(string)
+		union := len(response.Portfolio) + entry.Vaccines - len(entry.SharedVaccines)
+		entry.Jaccard = float64(len(entry.SharedVaccines)) / float64(union)
+		response.Entries = append(response.Entries, entry)
+	}
+
+	sort.Slice(response.Entries, func(i, j int) bool {
+		a, b := response.Entries[i], response.Entries[j]
+		if a.Jaccard != b.Jaccard {
+			return a.Jaccard > b.Jaccard
+		}
+		if len(a.SharedVaccines) != len(b.SharedVaccines) {
+			return len(a.SharedVaccines) > len(b.SharedVaccines)
+		}
+		return a.Country < b.Country
+	})
+	if len(response.Entries) > limit {
+		response.Entries = response.Entries[:limit]
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// stringList converts a Neo4j list of strings.
+func stringList(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
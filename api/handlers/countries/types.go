@@ -0,0 +1,18 @@
+// Package countries handles country-centred views of the graph.
+// Defines response data structures used across countries handlers.
+
+package countries
+
+type SimilarCountry struct {
+	Country        string   `json:"country"`
+	Name           string   `json:"name"`
+	SharedVaccines []string `json:"shared_vaccines"`
+	Vaccines       int      `json:"vaccines"`
+	Jaccard        float64  `json:"jaccard"`
+}
+
+type SimilarPortfolioResponse struct {
+	Country   string           `json:"country"`
+	Portfolio []string         `json:"portfolio"`
+	Entries   []SimilarCountry `json:"entries" table:"rows"`
+}
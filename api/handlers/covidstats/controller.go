@@ -1,25 +1,53 @@
 // Package covidstats handles COVID-19 case statistics.
 // This file routes incoming HTTP requests to the appropriate handler based on the URL and query parameters.
 //
-// It supports both accumulated and daily data, at country or global level.
+// It supports both accumulated and daily data, at country, region or global level.
+// The values are looked up through the metric registry (see handlers/stats).
 
 package covidstats
 
 import (
 	"net/http"
-	"strings"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+var (
+	cases  = registry.MustLookup("cases")
+	deaths = registry.MustLookup("deaths")
 )
 
 func CovidStatsController(w http.ResponseWriter, r *http.Request) {
-	country := chi.URLParam(r, "country")
-	date := chi.URLParam(r, "date")
-	onlyNews := strings.ToLower(r.URL.Query().Get("only-news")) == "true"
-
-	if onlyNews {
-		handleNew(w, country, date)
-	} else {
-		handleAccumulated(w, country, date)
+	var p stats.ValueParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
 	}
+
+	respond(w, r, p)
+}
+
+// respond responds with the last known cases and deaths *on or before* the
+// date or, with only-news, the new cases and deaths of the date, i.e. the
+// difference to the most recent prior date.
+func respond(w http.ResponseWriter, r *http.Request, p stats.ValueParams) {
+	scope, date, onlyNews := p.Scope(), string(p.Date), p.OnlyNews
+	values, err := stats.Values(r.Context(), "covidstats", []registry.Metric{cases, deaths}, scope, date, onlyNews)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	response := CovidStatsResponse{
+		Country:  scope.Label(),
+		Date:     date,
+		OnlyNews: onlyNews,
+		Cases:    int64(values[cases.Name]),
+		Deaths:   int64(values[deaths.Name]),
+	}
+
+	utils.Respond(w, r, response)
 }
@@ -1,17 +1,31 @@
 package covidstats
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/registry"
 	"github.com/go-chi/chi/v5"
 )
 
-func TestHandleNew_InvalidDate(t *testing.T) {
+// serve sends a GET request for path to the controller through its routes.
+func serve(path string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/covid-stats/{country}/{date}", CovidStatsController)
+	r.Get("/covid-stats/{date}", CovidStatsController)
+
 	rec := httptest.NewRecorder()
-	handleNew(rec, "", "invalid-date")
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestHandleNew_InvalidDate(t *testing.T) {
+	rec := serve("/covid-stats/invalid-date?only-news=true")
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
 	}
@@ -19,8 +33,7 @@ func TestHandleNew_InvalidDate(t *testing.T) {
 
 func TestHandleNew_FutureDate(t *testing.T) {
 	future := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
-	rec := httptest.NewRecorder()
-	handleNew(rec, "", future)
+	rec := serve("/covid-stats/" + future + "?only-news=true")
 	if rec.Code != http.StatusNotFound {
 		t.Errorf("expected status %d for future date, got %d", http.StatusNotFound, rec.Code)
 	}
@@ -29,8 +42,7 @@ func TestHandleNew_FutureDate(t *testing.T) {
 func TestHandleNew_PositiveGlobal(t *testing.T) {
 	// Date present in the test database
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleNew(rec, "", date)
+	rec := serve("/covid-stats/" + date + "?only-news=true")
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for valid global stats, got %d", http.StatusOK, rec.Code)
 	}
@@ -40,16 +52,14 @@ func TestHandleNew_PositiveCountry(t *testing.T) {
 	// Real ISO3 code and date present in the test database
 	country := "BRA"
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleNew(rec, country, date)
+	rec := serve("/covid-stats/" + country + "/" + date + "?only-news=true")
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for valid country stats, got %d", http.StatusOK, rec.Code)
 	}
 }
 
 func TestHandleAccumulated_InvalidDate(t *testing.T) {
-	rec := httptest.NewRecorder()
-	handleAccumulated(rec, "", "bad-date")
+	rec := serve("/covid-stats/bad-date")
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d for invalid date, got %d", http.StatusBadRequest, rec.Code)
 	}
@@ -58,8 +68,7 @@ func TestHandleAccumulated_InvalidDate(t *testing.T) {
 func TestHandleAccumulated_PositiveGlobal(t *testing.T) {
 	// Date present in the test database
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleAccumulated(rec, "", date)
+	rec := serve("/covid-stats/" + date)
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for global accumulated, got %d", http.StatusOK, rec.Code)
 	}
@@ -69,8 +78,7 @@ func TestHandleAccumulated_PositiveCountry(t *testing.T) {
 	// Real ISO3 code and date present in the test database
 	country := "BRA"
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleAccumulated(rec, country, date)
+	rec := serve("/covid-stats/" + country + "/" + date)
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for country accumulated, got %d", http.StatusOK, rec.Code)
 	}
@@ -82,18 +90,110 @@ func TestCovidStatsController_Routes(t *testing.T) {
 	r.Get("/covid-stats/{date}", CovidStatsController)
 
 	// Test global route
-	req1 := httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31?onlyNews=false", nil)
+	req1 := httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31?only-news=false", nil)
 	rec1 := httptest.NewRecorder()
 	r.ServeHTTP(rec1, req1)
 	if rec1.Code != http.StatusOK {
 		t.Errorf("expected status %d for global accumulated, got %d", http.StatusOK, rec1.Code)
 	}
 
-	// Test country route with onlyNews=true
-	req2 := httptest.NewRequest(http.MethodGet, "/covid-stats/BRA/2021-07-31?onlyNews=true", nil)
+	// Test country route with only-news=true
+	req2 := httptest.NewRequest(http.MethodGet, "/covid-stats/BRA/2021-07-31?only-news=true", nil)
 	rec2 := httptest.NewRecorder()
 	r.ServeHTTP(rec2, req2)
 	if rec2.Code != http.StatusOK {
 		t.Errorf("expected status %d for country new data, got %d", http.StatusOK, rec2.Code)
 	}
 }
+
+func TestCovidStatsController_UnknownParameter(t *testing.T) {
+	// The camel case spelling used to be silently ignored
+	rec := serve("/covid-stats/2021-07-31?onlyNews=true")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for unknown parameter, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCovidStatsController_LowercaseCountry(t *testing.T) {
+	// Real ISO3 code and date present in the test database
+	rec := serve("/covid-stats/bra/2021-07-31")
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d for lowercase country, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+// Queries used before the time-indexed model (NEXT links, LATEST_CASE
+// pointers and the (country, date) index), kept as the benchmark baseline.
+const (
+	legacyAccumulatedQuery = `
+		MATCH (c:Country {iso3: $country})-[:HAS_CASE]->(cc:CovidCase)
+		WHERE cc.date <= date($date)
+		RETURN cc.totalCases AS totalCases, cc.totalDeaths AS totalDeaths
+		ORDER BY cc.date DESC
+		LIMIT 1
+	`
+	legacyCurrentQuery = `
+		MATCH (c:Country {iso3: $country})-[:HAS_CASE]->(cc:CovidCase)
+		WHERE cc.date = date($date)
+		RETURN cc.totalCases AS totalCases, cc.totalDeaths AS totalDeaths
+	`
+	legacyPreviousQuery = `
+		MATCH (c:Country {iso3: $country})-[:HAS_CASE]->(cc:CovidCase)
+		WHERE cc.date < date($date)
+		RETURN cc.totalCases AS totalCases, cc.totalDeaths AS totalDeaths
+		ORDER BY cc.date DESC
+		LIMIT 1
+	`
+)
+
+// Both arms run the raw queries on the same inputs, so the comparison
+// leaves out routing and encoding. No results are recorded; measure against
+// the full OWID dataset with:
+//
+//	go test -run '^$' -bench . ./handlers/covidstats
+func BenchmarkAccumulatedCountry(b *testing.B) {
+	ctx := context.Background()
+	params := map[string]interface{}{"country": "BRA", "date": "2021-07-31"}
+	query := stats.ValueQuery([]registry.Metric{cases, deaths}, stats.Scope{Country: "BRA"}, false)
+
+	b.Run("legacy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := neo4j.Query(ctx, "bench.legacy.accumulated", legacyAccumulatedQuery, params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("time-indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := neo4j.Query(ctx, "bench.time-indexed.accumulated", query, params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkNewCountry(b *testing.B) {
+	ctx := context.Background()
+	params := map[string]interface{}{"country": "BRA", "date": "2021-07-31"}
+	query := stats.ValueQuery([]registry.Metric{cases, deaths}, stats.Scope{Country: "BRA"}, true)
+
+	b.Run("legacy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := neo4j.Query(ctx, "bench.legacy.new.current", legacyCurrentQuery, params); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := neo4j.Query(ctx, "bench.legacy.new.previous", legacyPreviousQuery, params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("time-indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := neo4j.Query(ctx, "bench.time-indexed.new", query, params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,47 @@
+// Package export exports the country/vaccine graph in formats understood
+// by graph tools such as Gephi, Cytoscape and Graphviz.
+// This file writes the graph as Cytoscape JSON.
+
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+)
+
+// writeCytoscape writes the graph in the Cytoscape.js elements JSON format,
+// which Cytoscape desktop also imports. Each element is encoded on its own
+// rather than as one JSON document.
+func writeCytoscape(w *bufio.Writer, g Graph) {
+	w.WriteString(`{"elements":{"nodes":[`)
+	for i, node := range g.Nodes {
+		data := cytoscapeData(node.data(true))
+		data["id"] = node.ID
+		cytoscapeElement(w, i, data)
+	}
+	w.WriteString(`],"edges":[`)
+	for i, edge := range g.Edges {
+		data := cytoscapeData(edge.data())
+		data["id"], data["source"], data["target"] = edge.ID, edge.Source, edge.Target
+		cytoscapeElement(w, i, data)
+	}
+	w.WriteString("]}}\n")
+}
+
+// cytoscapeData maps the properties to an element's data. Lists are kept
+// as JSON arrays.
+func cytoscapeData(properties []Property) map[string]interface{} {
+	data := make(map[string]interface{}, len(properties)+3)
+	for _, p := range properties {
+		data[p.Key] = p.Value
+	}
+	return data
+}
+
+func cytoscapeElement(w *bufio.Writer, i int, data map[string]interface{}) {
+	if i > 0 {
+		w.WriteByte(',')
+	}
+	element, _ := json.Marshal(map[string]interface{}{"data": data})
+	w.Write(element)
+}
@@ -0,0 +1,45 @@
+// Package export exports the country/vaccine graph in formats understood
+// by graph tools such as Gephi, Cytoscape and Graphviz.
+// This file writes the graph in the Graphviz DOT language.
+
+package export
+
+import (
+	"bufio"
+	"strings"
+)
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeDOT writes the graph as a Graphviz digraph, with the properties as
+// node and edge attributes.
+func writeDOT(w *bufio.Writer, g Graph) {
+	w.WriteString("digraph viralgraph {\n")
+	for _, node := range g.Nodes {
+		w.WriteString("  " + quoteDOT(node.ID) + " ")
+		dotAttributes(w, node.data(true))
+		w.WriteString(";\n")
+	}
+	for _, edge := range g.Edges {
+		w.WriteString("  " + quoteDOT(edge.Source) + " -> " + quoteDOT(edge.Target) + " ")
+		dotAttributes(w, edge.data())
+		w.WriteString(";\n")
+	}
+	w.WriteString("}\n")
+}
+
+func dotAttributes(w *bufio.Writer, properties []Property) {
+	w.WriteByte('[')
+	for i, p := range properties {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(p.Key + "=" + quoteDOT(text(p.Value)))
+	}
+	w.WriteByte(']')
+}
+
+// quoteDOT quotes an ID or attribute value as a DOT string.
+func quoteDOT(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
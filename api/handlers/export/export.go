@@ -0,0 +1,263 @@
+// Package export exports the country/vaccine graph in formats understood
+// by graph tools such as Gephi, Cytoscape and Graphviz.
+//
+// Exports the selected subgraph (countries, vaccines and the USES
+// relationships between them) with node and relationship properties as
+// GraphML, GEXF, Cytoscape JSON or DOT. Countries can be narrowed down to
+// a region and vaccines to a platform; relationships are only exported
+// between exported nodes.
+
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+const (
+	includeCountries = "countries"
+	includeVaccines  = "vaccines"
+	includeUses      = "uses"
+)
+
+// format is an export format and how its files are served.
+type format struct {
+	contentType string
+	extension   string
+	write       func(w *bufio.Writer, g Graph)
+}
+
+var formats = map[string]format{
+	"graphml":   {contentType: "application/graphml+xml", extension: "graphml", write: writeGraphML},
+	"gexf":      {contentType: "application/gexf+xml", extension: "gexf", write: writeGEXF},
+	"cytoscape": {contentType: "application/json", extension: "cyjs", write: writeCytoscape},
+	"dot":       {contentType: "text/vnd.graphviz", extension: "dot", write: writeDOT},
+}
+
+// exportSpec selects what is exported: the format, the parts of the graph
+// (a comma separated list, default everything), and the region and
+// platform narrowing down countries and vaccines.
+type exportSpec struct {
+	Format   string   `query:"format" default:"graphml" enum:"graphml,gexf,cytoscape,dot"`
+	Include  []string `query:"include" default:"countries,vaccines,uses" enum:"countries,vaccines,uses"`
+	Region   string   `query:"region"`
+	Platform string   `query:"platform"`
+}
+
+// Validate checks that relationships are exported with both their ends.
+func (spec exportSpec) Validate() error {
+	if spec.includes(includeUses) && !(spec.includes(includeCountries) && spec.includes(includeVaccines)) {
+		return utils.InvalidParam("include", "Exporting uses requires including countries and vaccines")
+	}
+	return nil
+}
+
+func (spec exportSpec) includes(item string) bool {
+	return slices.Contains(spec.Include, item)
+}
+
+func HandleExportGraph(w http.ResponseWriter, r *http.Request) {
+	var spec exportSpec
+	if err := param.Parse(r, &spec); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	g, err := load(r.Context(), spec)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+
+	f := formats[spec.Format]
+	w.Header().Set("Content-Type", f.contentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="viralgraph.%s"`, f.extension))
+	bw := bufio.NewWriter(w)
+	f.write(bw, g)
+	bw.Flush()
+}
+
+// load reads the selected nodes and relationships. The whole subgraph is
+// loaded before writing, since GraphML and GEXF declare every property key
+// before the first element.
+func load(ctx context.Context, spec exportSpec) (Graph, error) {
+	params := map[string]interface{}{"region": spec.Region, "platform": strings.ToLower(spec.Platform)}
+	g := Graph{Nodes: []Node{}, Edges: []Edge{}}
+
+	if spec.includes(includeCountries) {
+		nodes, err := loadCountries(ctx, params)
+		if err != nil {
+			return g, err
+		}
+		g.Nodes = append(g.Nodes, nodes...)
+	}
+	if spec.includes(includeVaccines) {
+		nodes, err := loadVaccines(ctx, params)
+		if err != nil {
+			return g, err
+		}
+		g.Nodes = append(g.Nodes, nodes...)
+	}
+	if spec.includes(includeUses) {
+		edges, err := loadUses(ctx, params)
+		if err != nil {
+			return g, err
+		}
+		g.Edges = edges
+	}
+	return g, nil
+}
+
+func loadCountries(ctx context.Context, params map[string]interface{}) ([]Node, error) {
+	query := `
+		MATCH (c:Country)
+		WHERE $region = '' OR c.region = $region
+		RETURN c.iso3 AS iso3, c.name AS name, c.id AS id, c.region AS region
+		ORDER BY iso3
+	`
+	records, err := neo4j.Query(ctx, "export.countries", query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]Node, 0, len(records))
+	for _, record := range records {
+		iso3, _ := record.Get("iso3")
+		name, _ := record.Get("name")
+		id, _ := record.Get("id")
+		region, _ := record.Get("region")
+
+		node := Node{ID: "country:" + fmt.Sprint(iso3), Type: "Country"}
+		node.Label, _ = name.(string)
+		node.Properties = properties(
+			Property{"iso3", iso3},
+			Property{"name", name},
+			Property{"country_id", id},
+			Property{"region", region},
+		)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func loadVaccines(ctx context.Context, params map[string]interface{}) ([]Node, error) {
+	query := `
+		MATCH (v:Vaccine)
+		OPTIONAL MATCH (v)-[:USES_PLATFORM]->(p:Platform)
+		WITH v, p
+		WHERE $platform = '' OR toLower(p.name) = $platform
+		CALL {
+			WITH v
+			OPTIONAL MATCH (v)-[:MADE_BY]->(m:Manufacturer)
+			WITH m ORDER BY m.name
+			RETURN collect(m.name) AS manufacturers
+		}
+		RETURN coalesce(v.slug, v.name) AS key, v.id AS id, v.slug AS slug, v.name AS name,
+			p.name AS platform, manufacturers, v.doses AS doses,
+			v.dose_interval_days AS interval, toString(v.first_global_use) AS first_global_use
+		ORDER BY id
+	`
+	records, err := neo4j.Query(ctx, "export.vaccines", query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]Node, 0, len(records))
+	for _, record := range records {
+		key, _ := record.Get("key")
+		id, _ := record.Get("id")
+		slug, _ := record.Get("slug")
+		name, _ := record.Get("name")
+		platform, _ := record.Get("platform")
+		manufacturers, _ := record.Get("manufacturers")
+		doses, _ := record.Get("doses")
+		interval, _ := record.Get("interval")
+		firstGlobalUse, _ := record.Get("first_global_use")
+
+		node := Node{ID: "vaccine:" + fmt.Sprint(key), Type: "Vaccine"}
+		node.Label, _ = name.(string)
+		node.Properties = properties(
+			Property{"vaccine_id", id},
+			Property{"slug", slug},
+			Property{"name", name},
+			Property{"platform", platform},
+			Property{"manufacturers", stringList(manufacturers)},
+			Property{"doses", doses},
+			Property{"dose_interval_days", interval},
+			Property{"first_global_use", firstGlobalUse},
+		)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func loadUses(ctx context.Context, params map[string]interface{}) ([]Edge, error) {
+	query := `
+		MATCH (c:Country)-[u:USES]->(v:Vaccine)
+		WHERE $region = '' OR c.region = $region
+		OPTIONAL MATCH (v)-[:USES_PLATFORM]->(p:Platform)
+		WITH c, u, v, p
+		WHERE $platform = '' OR toLower(p.name) = $platform
+		RETURN c.iso3 AS country, coalesce(v.slug, v.name) AS vaccine,
+			toString(u.first_used) AS first_used
+		ORDER BY country, vaccine
+	`
+	records, err := neo4j.Query(ctx, "export.uses", query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]Edge, 0, len(records))
+	for _, record := range records {
+		country, _ := record.Get("country")
+		vaccine, _ := record.Get("vaccine")
+		firstUsed, _ := record.Get("first_used")
+
+		edges = append(edges, Edge{
+			ID:         fmt.Sprintf("uses:%v:%v", country, vaccine),
+			Source:     "country:" + fmt.Sprint(country),
+			Target:     "vaccine:" + fmt.Sprint(vaccine),
+			Type:       "USES",
+			Properties: properties(Property{"first_used", firstUsed}),
+		})
+	}
+	return edges, nil
+}
+
+// properties drops the properties without a value (null in the database,
+// or an empty list).
+func properties(all ...Property) []Property {
+	result := make([]Property, 0, len(all))
+	for _, p := range all {
+		switch v := p.Value.(type) {
+		case nil:
+			continue
+		case []string:
+			if len(v) == 0 {
+				continue
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// stringList converts a Neo4j list of strings.
+func stringList(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testGraph() Graph {
+	return Graph{
+		Nodes: []Node{
+			{ID: "country:BRA", Type: "Country", Label: "Brazil", Properties: []Property{
+				{"iso3", "BRA"}, {"name", "Brazil"}, {"country_id", int64(1)},
+			}},
+			{ID: "vaccine:pfizer-biontech", Type: "Vaccine", Label: `Pfizer/BioNTech "mRNA" & co`, Properties: []Property{
+				{"vaccine_id", int64(7)}, {"manufacturers", []string{"BioNTech", "Pfizer"}},
+			}},
+		},
+		Edges: []Edge{
+			{ID: "uses:BRA:pfizer-biontech", Source: "country:BRA", Target: "vaccine:pfizer-biontech", Type: "USES", Properties: []Property{
+				{"first_used", "2021-05-03"},
+			}},
+		},
+	}
+}
+
+func write(f func(*bufio.Writer, Graph), g Graph) string {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	f(w, g)
+	w.Flush()
+	return b.String()
+}
+
+func TestHandleExportGraph_InvalidParams(t *testing.T) {
+	for _, path := range []string{
+		"/export/graph?format=csv",
+		"/export/graph?include=countries,regions",
+		"/export/graph?include=countries,uses",
+	} {
+		rec := httptest.NewRecorder()
+		HandleExportGraph(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestWriteXMLFormats(t *testing.T) {
+	for name, f := range map[string]func(*bufio.Writer, Graph){"graphml": writeGraphML, "gexf": writeGEXF} {
+		out := write(f, testGraph())
+		decoder := xml.NewDecoder(strings.NewReader(out))
+		for {
+			_, err := decoder.Token()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("%s: invalid XML: %v", name, err)
+				}
+				break
+			}
+		}
+		if !strings.Contains(out, `source="country:BRA" target="vaccine:pfizer-biontech"`) {
+			t.Errorf("%s: expected the USES edge, got %s", name, out)
+		}
+		if !strings.Contains(out, "BioNTech|Pfizer") {
+			t.Errorf("%s: expected lists joined with |, got %s", name, out)
+		}
+	}
+
+	out := write(writeGraphML, testGraph())
+	if !strings.Contains(out, `attr.name="country_id" attr.type="long"`) {
+		t.Errorf("expected typed GraphML keys, got %s", out)
+	}
+}
+
+func TestWriteCytoscape(t *testing.T) {
+	var doc struct {
+		Elements struct {
+			Nodes []struct{ Data map[string]interface{} }
+			Edges []struct{ Data map[string]interface{} }
+		}
+	}
+	out := write(writeCytoscape, testGraph())
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(doc.Elements.Nodes) != 2 || len(doc.Elements.Edges) != 1 {
+		t.Fatalf("expected 2 nodes and 1 edge, got %+v", doc.Elements)
+	}
+	if doc.Elements.Nodes[0].Data["id"] != "country:BRA" || doc.Elements.Nodes[0].Data["type"] != "Country" {
+		t.Errorf("unexpected node data %v", doc.Elements.Nodes[0].Data)
+	}
+	if doc.Elements.Edges[0].Data["source"] != "country:BRA" || doc.Elements.Edges[0].Data["first_used"] != "2021-05-03" {
+		t.Errorf("unexpected edge data %v", doc.Elements.Edges[0].Data)
+	}
+
+	if out := write(writeCytoscape, Graph{}); out != `{"elements":{"nodes":[],"edges":[]}}`+"\n" {
+		t.Errorf("unexpected empty graph %s", out)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	out := write(writeDOT, testGraph())
+	if !strings.Contains(out, `"country:BRA" -> "vaccine:pfizer-biontech" [type="USES", first_used="2021-05-03"];`) {
+		t.Errorf("expected the USES edge, got %s", out)
+	}
+	if !strings.Contains(out, `label="Pfizer/BioNTech \"mRNA\" & co"`) {
+		t.Errorf("expected escaped quotes, got %s", out)
+	}
+}
+
+func TestHandleExportGraph_Positive(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleExportGraph(rec, httptest.NewRequest(http.MethodGet, "/export/graph?format=cytoscape", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var doc struct {
+		Elements struct {
+			Nodes []json.RawMessage
+			Edges []json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Elements.Nodes) == 0 || len(doc.Elements.Edges) == 0 {
+		t.Error("expected nodes and edges in the export")
+	}
+}
@@ -0,0 +1,63 @@
+// Package export exports the country/vaccine graph in formats understood
+// by graph tools such as Gephi, Cytoscape and Graphviz.
+// This file writes the graph as GEXF, Gephi's native format.
+
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+)
+
+// writeGEXF writes the graph as a GEXF 1.3 document. Node labels go in the
+// label attribute and the remaining properties in declared attributes.
+func writeGEXF(w *bufio.Writer, g Graph) {
+	nodeAttributes := attributes(nodeData(g.Nodes, false))
+	edgeAttributes := attributes(edgeData(g.Edges))
+
+	w.WriteString(xml.Header)
+	w.WriteString(`<gexf xmlns="http://gexf.net/1.3" version="1.3">` + "\n")
+	w.WriteString(`  <graph mode="static" defaultedgetype="directed">` + "\n")
+	nodeIDs := gexfAttributes(w, "node", nodeAttributes)
+	edgeIDs := gexfAttributes(w, "edge", edgeAttributes)
+
+	w.WriteString("    <nodes>\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(w, "      <node id=\"%s\" label=\"%s\">\n", escape(node.ID), escape(node.Label))
+		gexfValues(w, nodeIDs, node.data(false))
+		w.WriteString("      </node>\n")
+	}
+	w.WriteString("    </nodes>\n")
+
+	w.WriteString("    <edges>\n")
+	for _, edge := range g.Edges {
+		fmt.Fprintf(w, "      <edge id=\"%s\" source=\"%s\" target=\"%s\" label=\"%s\">\n", escape(edge.ID), escape(edge.Source), escape(edge.Target), escape(edge.Type))
+		gexfValues(w, edgeIDs, edge.data())
+		w.WriteString("      </edge>\n")
+	}
+	w.WriteString("    </edges>\n")
+	w.WriteString("  </graph>\n")
+	w.WriteString("</gexf>\n")
+}
+
+// gexfAttributes declares the attributes of one element class and returns
+// their IDs by property name.
+func gexfAttributes(w *bufio.Writer, class string, attributes []attribute) map[string]string {
+	ids := make(map[string]string, len(attributes))
+	fmt.Fprintf(w, "    <attributes class=\"%s\">\n", class)
+	for i, a := range attributes {
+		ids[a.Key] = fmt.Sprint(i)
+		fmt.Fprintf(w, "      <attribute id=\"%d\" title=\"%s\" type=\"%s\"/>\n", i, escape(a.Key), a.Kind)
+	}
+	w.WriteString("    </attributes>\n")
+	return ids
+}
+
+func gexfValues(w *bufio.Writer, ids map[string]string, properties []Property) {
+	w.WriteString("        <attvalues>\n")
+	for _, p := range properties {
+		fmt.Fprintf(w, "          <attvalue for=\"%s\" value=\"%s\"/>\n", ids[p.Key], escape(text(p.Value)))
+	}
+	w.WriteString("        </attvalues>\n")
+}
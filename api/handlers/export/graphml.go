@@ -0,0 +1,62 @@
+// Package export exports the country/vaccine graph in formats understood
+// by graph tools such as Gephi, Cytoscape and Graphviz.
+// This file writes the graph as GraphML.
+
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// writeGraphML writes the graph as a GraphML document, declaring one key
+// per node and edge property.
+func writeGraphML(w *bufio.Writer, g Graph) {
+	nodeKeys := attributes(nodeData(g.Nodes, true))
+	edgeKeys := attributes(edgeData(g.Edges))
+
+	w.WriteString(xml.Header)
+	w.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	nodeIDs := graphMLKeys(w, "node", "n", nodeKeys)
+	edgeIDs := graphMLKeys(w, "edge", "e", edgeKeys)
+
+	w.WriteString(`  <graph id="viralgraph" edgedefault="directed">` + "\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(w, "    <node id=\"%s\">\n", escape(node.ID))
+		graphMLData(w, nodeIDs, node.data(true))
+		w.WriteString("    </node>\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(w, "    <edge id=\"%s\" source=\"%s\" target=\"%s\">\n", escape(edge.ID), escape(edge.Source), escape(edge.Target))
+		graphMLData(w, edgeIDs, edge.data())
+		w.WriteString("    </edge>\n")
+	}
+	w.WriteString("  </graph>\n")
+	w.WriteString("</graphml>\n")
+}
+
+// graphMLKeys declares the keys of one element type and returns their IDs
+// by property name.
+func graphMLKeys(w *bufio.Writer, element, prefix string, keys []attribute) map[string]string {
+	ids := make(map[string]string, len(keys))
+	for i, key := range keys {
+		ids[key.Key] = fmt.Sprintf("%s%d", prefix, i)
+		fmt.Fprintf(w, "  <key id=\"%s\" for=\"%s\" attr.name=\"%s\" attr.type=\"%s\"/>\n", ids[key.Key], element, escape(key.Key), key.Kind)
+	}
+	return ids
+}
+
+func graphMLData(w *bufio.Writer, ids map[string]string, properties []Property) {
+	for _, p := range properties {
+		fmt.Fprintf(w, "      <data key=\"%s\">%s</data>\n", ids[p.Key], escape(text(p.Value)))
+	}
+}
+
+// escape escapes text for use in XML content and attribute values.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,129 @@
+// Package export exports the country/vaccine graph in formats understood
+// by graph tools such as Gephi, Cytoscape and Graphviz.
+// Defines the format-independent graph the writers encode.
+
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Property is a node or edge property. Values are strings, int64, float64,
+// bool or []string; properties without a value are left out of the graph.
+type Property struct {
+	Key   string
+	Value interface{}
+}
+
+// Node is a Country or Vaccine node. IDs are prefixed with the node type
+// (e.g. "country:BRA", "vaccine:pfizer-biontech") to be unique across types.
+type Node struct {
+	ID         string
+	Type       string
+	Label      string
+	Properties []Property
+}
+
+// Edge is a USES relationship from a country to a vaccine.
+type Edge struct {
+	ID         string
+	Source     string
+	Target     string
+	Type       string
+	Properties []Property
+}
+
+// Graph is the exported subgraph, with nodes and edges in a stable order.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// attribute describes a property key used by at least one node or edge,
+// for formats that declare their attributes up front.
+type attribute struct {
+	Key  string
+	Kind string // "string", "long", "double" or "boolean"
+}
+
+// attributes lists the property keys used by the elements, in order of
+// first appearance. The first type seen for a key wins.
+func attributes(properties [][]Property) []attribute {
+	seen := map[string]bool{}
+	var result []attribute
+	for _, list := range properties {
+		for _, p := range list {
+			if seen[p.Key] {
+				continue
+			}
+			seen[p.Key] = true
+			result = append(result, attribute{Key: p.Key, Kind: kind(p.Value)})
+		}
+	}
+	return result
+}
+
+func kind(value interface{}) string {
+	switch value.(type) {
+	case int64:
+		return "long"
+	case float64:
+		return "double"
+	case bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// data returns the node's type and properties, preceded by its label when
+// the format has no dedicated place for it.
+func (n Node) data(withLabel bool) []Property {
+	result := []Property{{Key: "type", Value: n.Type}}
+	if withLabel {
+		result = append(result, Property{Key: "label", Value: n.Label})
+	}
+	return append(result, n.Properties...)
+}
+
+// data returns the edge's type and properties.
+func (e Edge) data() []Property {
+	return append([]Property{{Key: "type", Value: e.Type}}, e.Properties...)
+}
+
+func nodeData(nodes []Node, withLabel bool) [][]Property {
+	result := make([][]Property, len(nodes))
+	for i, node := range nodes {
+		result[i] = node.data(withLabel)
+	}
+	return result
+}
+
+func edgeData(edges []Edge) [][]Property {
+	result := make([][]Property, len(edges))
+	for i, edge := range edges {
+		result[i] = edge.data()
+	}
+	return result
+}
+
+// text formats a property value for text-based formats. Lists are joined
+// with "|", as in the ETL's CSV files.
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, "|")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,37 @@
+// Package hospitalstats handles COVID-19 hospitalisation statistics.
+// This file binds the /hospital-stats endpoints to their metrics.
+//
+// It supports both accumulated and daily data, at country, region or global level.
+// The values are readings (patients on a day, admissions over the last week),
+// so the accumulated value is the latest reading on or before the date and the
+// daily value is the reading of the date itself (see registry.Gauge).
+
+package hospitalstats
+
+import (
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/registry"
+)
+
+var (
+	hospitalPatients   = registry.MustLookup("hospital-patients")
+	icuPatients        = registry.MustLookup("icu-patients")
+	weeklyHospitalised = registry.MustLookup("weekly-hospital-admissions")
+)
+
+// HospitalStatsController responds with the last known readings *on or
+// before* the date or, with only-news, the readings reported on the date.
+// Readings without a value are omitted.
+var HospitalStatsController = stats.GroupHandler("hospitalstats",
+	[]registry.Metric{hospitalPatients, icuPatients, weeklyHospitalised},
+	func(p stats.ValueParams, values map[string]float64) interface{} {
+		return HospitalStatsResponse{
+			Country:                  p.Scope().Label(),
+			Date:                     string(p.Date),
+			OnlyNews:                 p.OnlyNews,
+			HospitalPatients:         stats.Count(values, hospitalPatients),
+			ICUPatients:              stats.Count(values, icuPatients),
+			WeeklyHospitalAdmissions: stats.Count(values, weeklyHospitalised),
+		}
+	},
+)
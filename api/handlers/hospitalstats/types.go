@@ -0,0 +1,15 @@
+// Package hospitalstats handles COVID-19 hospitalisation statistics.
+// Defines response data structures used across hospitalisation statistics handlers.
+
+package hospitalstats
+
+// The readings are missing when the country (or, for a region or worldwide,
+// every country) did not report them.
+type HospitalStatsResponse struct {
+	Country                  string `json:"country"`
+	Date                     string `json:"date"`
+	OnlyNews                 bool   `json:"only_news"`
+	HospitalPatients         *int64 `json:"hospital_patients,omitempty"`
+	ICUPatients              *int64 `json:"icu_patients,omitempty"`
+	WeeklyHospitalAdmissions *int64 `json:"weekly_hospital_admissions,omitempty"`
+}
@@ -0,0 +1,267 @@
+// Package query handles the generic declarative query endpoint.
+// This file validates a Spec and compiles it into parameterised Cypher.
+//
+// Only whitelisted names (metrics, group keys, aggregations, sort orders)
+// are written into the query text; every user-supplied value (countries,
+// region, dates, limit) is passed as a parameter.
+
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+const (
+	defaultLimit       = 100
+	maxLimit           = 5000
+	maxCountries       = 300
+	defaultAggregation = "last"
+	defaultFrom        = "2020-01-01"
+)
+
+var groupKeys = map[string]string{
+	"country": "c.iso3",
+	"region":  "c.region",
+	"date":    "toString(date)",
+	"month":   "substring(toString(date), 0, 7)",
+	"year":    "date.year",
+}
+
+var aggregations = map[string]string{
+	"sum":  "sum(%s)",
+	"avg":  "avg(%s)",
+	"min":  "min(%s)",
+	"max":  "max(%s)",
+	"last": "last(collect(%s))",
+}
+
+var countryCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// compiled is a Spec ready to be executed.
+type compiled struct {
+	cypher  string
+	params  map[string]interface{}
+	columns []string
+	limit   int
+}
+
+// compile validates the spec, fills in defaults and builds the Cypher query.
+//
+// Rows are grouped by the group_by keys (by default country and date, i.e.
+// one row per country and day) and each metric is aggregated within its
+// group (by default with "last", the most recent value). An explicit empty
+// group_by aggregates everything into a single row.
+func compile(spec Spec) (*compiled, error) {
+	if len(spec.Metrics) == 0 {
+		return nil, invalid("metrics", "At least one metric is required")
+	}
+	metrics := make([]string, 0, len(spec.Metrics))
+	sources := map[string]bool{}
+	for _, metric := range spec.Metrics {
+		source, ok := registry.Lookup(metric)
+		if !ok {
+			return nil, invalid("metrics", "Unknown metric %q. Use one of: %s", metric, strings.Join(registry.Names(), ", "))
+		}
+		if slices.Contains(metrics, metric) {
+			return nil, invalid("metrics", "Metric %q is repeated", metric)
+		}
+		metrics = append(metrics, metric)
+		sources[source.Label] = true
+	}
+
+	params := map[string]interface{}{}
+	where := ""
+	switch {
+	case len(spec.Countries) > 0 && spec.Region != "":
+		return nil, invalid("countries", "Use either countries or region, not both")
+	case len(spec.Countries) > maxCountries:
+		return nil, invalid("countries", "At most %d countries are allowed", maxCountries)
+	case len(spec.Countries) > 0:
+		countries := make([]string, len(spec.Countries))
+		for i, country := range spec.Countries {
+			countries[i] = strings.ToUpper(strings.TrimSpace(country))
+			if !countryCode.MatchString(countries[i]) {
+				return nil, invalid("countries", "Invalid country %q. Use ISO3 codes (e.g. BRA).", country)
+			}
+		}
+		params["countries"] = countries
+		where = "WHERE c.iso3 IN $countries"
+	case spec.Region != "":
+		params["region"] = spec.Region
+		where = "WHERE c.region = $region"
+	}
+
+	from, to := spec.From, spec.To
+	if from == "" {
+		from = defaultFrom
+	}
+	if to == "" {
+		to = time.Now().Format("2006-01-02")
+	}
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, invalid("from", "Invalid date format in from. Use YYYY-MM-DD.")
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, invalid("to", "Invalid date format in to. Use YYYY-MM-DD.")
+	}
+	if fromDate.After(toDate) {
+		return nil, invalid("from", "from must not be after to")
+	}
+	params["from"], params["to"] = from, to
+
+	aggregation := spec.Aggregation
+	if aggregation == "" {
+		aggregation = defaultAggregation
+	}
+	aggregate, ok := aggregations[aggregation]
+	if !ok {
+		return nil, invalid("aggregation", "Unknown aggregation %q. Use one of: %s", aggregation, names(aggregations))
+	}
+
+	groupBy := spec.GroupBy
+	if groupBy == nil {
+		groupBy = []string{"country", "date"}
+	}
+	for i, key := range groupBy {
+		if _, ok := groupKeys[key]; !ok {
+			return nil, invalid("group_by", "Unknown group_by key %q. Use one of: %s", key, names(groupKeys))
+		}
+		if slices.Contains(groupBy[:i], key) {
+			return nil, invalid("group_by", "group_by key %q is repeated", key)
+		}
+	}
+	columns := append(append([]string{}, groupBy...), metrics...)
+
+	limit := spec.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit < 0 || limit > maxLimit {
+		return nil, invalid("limit", "limit must be between 1 and %d", maxLimit)
+	}
+	// One extra row tells whether the result was truncated.
+	params["limit"] = limit + 1
+
+	sortSpecs := spec.Sort
+	if len(sortSpecs) == 0 {
+		for _, key := range groupBy {
+			sortSpecs = append(sortSpecs, SortSpec{Field: key})
+		}
+	}
+	var orderBy []string
+	for _, s := range sortSpecs {
+		if !slices.Contains(columns, s.Field) {
+			return nil, invalid("sort", "Cannot sort by %q. Sort fields must be group_by keys or metrics.", s.Field)
+		}
+		switch strings.ToLower(s.Order) {
+		case "", "asc":
+			orderBy = append(orderBy, quote(s.Field))
+		case "desc":
+			orderBy = append(orderBy, quote(s.Field)+" DESC")
+		default:
+			return nil, invalid("sort", "Invalid sort order %q. Use asc or desc.", s.Order)
+		}
+	}
+
+	// One UNION branch per node type holding requested metrics, in registry
+	// order for stable queries. Every branch returns every metric column,
+	// with null for metrics it does not hold.
+	var branches []string
+	for _, label := range registry.Labels() {
+		if !sources[label] {
+			continue
+		}
+		var relationship string
+		returns := []string{"s.date AS date"}
+		for _, metric := range metrics {
+			source, _ := registry.Lookup(metric)
+			if source.Label == label {
+				relationship = source.Relationship
+				returns = append(returns, fmt.Sprintf("s.%s AS %s", source.Property, quote(metric)))
+			} else {
+				returns = append(returns, "null AS "+quote(metric))
+			}
+		}
+		branches = append(branches, fmt.Sprintf(`		WITH c
+		MATCH (c)-[:%s]->(s:%s)
+		WHERE s.date >= date($from) AND s.date <= date($to)
+		RETURN %s`, relationship, label, strings.Join(returns, ", ")))
+	}
+
+	var projections []string
+	for _, key := range groupBy {
+		projections = append(projections, groupKeys[key]+" AS "+key)
+	}
+	quoted := make([]string, len(metrics))
+	for i, metric := range metrics {
+		quoted[i] = quote(metric)
+		projections = append(projections, fmt.Sprintf(aggregate, quoted[i])+" AS "+quoted[i])
+	}
+	returns := make([]string, len(columns))
+	for i, column := range columns {
+		returns[i] = quote(column)
+	}
+
+	cypher := fmt.Sprintf(`
+	MATCH (c:Country)
+	%s
+	CALL {
+%s
+	}
+	WITH c, date, %s
+	ORDER BY date
+	WITH %s
+	RETURN %s
+	%s
+	LIMIT $limit
+	`,
+		where,
+		strings.Join(branches, "\n\t\tUNION ALL\n"),
+		strings.Join(quoted, ", "),
+		strings.Join(projections, ", "),
+		strings.Join(returns, ", "),
+		orderByClause(orderBy),
+	)
+
+	return &compiled{cypher: cypher, params: params, columns: columns, limit: limit}, nil
+}
+
+// invalid reports an invalid field of the query spec.
+func invalid(field, format string, args ...interface{}) *utils.Problem {
+	return utils.NewProblem(utils.CodeInvalidBody, fmt.Sprintf(format, args...)).WithParam(field)
+}
+
+// orderByClause builds the ORDER BY clause, which is omitted when there is
+// nothing to sort by (a single aggregated row).
+func orderByClause(orderBy []string) string {
+	if len(orderBy) == 0 {
+		return ""
+	}
+	return "ORDER BY " + strings.Join(orderBy, ", ")
+}
+
+// quote escapes a column name for use in Cypher, since metric names may
+// contain dashes (e.g. "fully-vaccinated").
+func quote(name string) string {
+	return "`" + name + "`"
+}
+
+// names lists the keys of a whitelist, sorted, for error messages.
+func names[V any](whitelist map[string]V) string {
+	keys := make([]string, 0, len(whitelist))
+	for key := range whitelist {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,79 @@
+// Package query handles the generic declarative query endpoint.
+// This file decodes the request, runs the compiled query and builds the
+// tabular response.
+//
+// The result size is bounded by the spec's limit (at most maxLimit rows)
+// and the execution time by a server-side transaction timeout.
+
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+const (
+	// maxBodyBytes bounds the size of the request body.
+	maxBodyBytes = 64 << 10
+
+	// timeout bounds the execution time of a compiled query.
+	timeout = 10 * time.Second
+)
+
+func HandleQuery(w http.ResponseWriter, r *http.Request) {
+	spec, err := decodeSpec(w, r)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	c, err := compile(spec)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	records, err := neo4j.Query(r.Context(), "query.generic", c.cypher, c.params, neo4j.WithTxTimeout(timeout))
+	if neo4j.IsTimeout(err) {
+		utils.RespondWithError(w, utils.NewProblem(utils.CodeQueryTimeout, "The query took too long. Narrow the date range, countries or grouping."))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, utils.NewProblem(utils.CodeDatabaseError, "Failed to run query"))
+		return
+	}
+
+	response := QueryResponse{
+		Columns: c.columns,
+		Rows:    make([]map[string]interface{}, 0, min(len(records), c.limit)),
+	}
+	for i, record := range records {
+		if i == c.limit {
+			response.Truncated = true
+			break
+		}
+		row := make(map[string]interface{}, len(c.columns))
+		for _, column := range c.columns {
+			row[column], _ = record.Get(column)
+		}
+		response.Rows = append(response.Rows, row)
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// decodeSpec reads the query spec from the body, rejecting unknown fields
+// so that typos are reported instead of silently ignored.
+func decodeSpec(w http.ResponseWriter, r *http.Request) (Spec, error) {
+	var spec Spec
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return Spec{}, utils.NewProblem(utils.CodeInvalidBody, "Invalid request body. Send a JSON query spec: "+err.Error())
+	}
+	return spec, nil
+}
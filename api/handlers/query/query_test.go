@@ -0,0 +1,114 @@
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCompile_Defaults(t *testing.T) {
+	c, err := compile(Spec{Metrics: []string{"cases"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(c.columns, ","); got != "country,date,cases" {
+		t.Errorf("expected columns country,date,cases, got %s", got)
+	}
+	if c.limit != defaultLimit || c.params["limit"] != defaultLimit+1 {
+		t.Errorf("expected limit %d (fetching %d), got %d (fetching %v)", defaultLimit, defaultLimit+1, c.limit, c.params["limit"])
+	}
+	if strings.Contains(c.cypher, "VaccinationStats") {
+		t.Error("expected no VaccinationStats branch when only case metrics are requested")
+	}
+}
+
+func TestCompile_ValuesArePassedAsParameters(t *testing.T) {
+	c, err := compile(Spec{
+		Metrics:   []string{"deaths", "vaccinated"},
+		Countries: []string{"bra", "ARG"},
+		From:      "2021-01-01",
+		To:        "2021-12-31",
+		GroupBy:   []string{"country", "month"},
+		Sort:      []SortSpec{{Field: "deaths", Order: "desc"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, value := range []string{"BRA", "ARG", "2021-01-01", "2021-12-31"} {
+		if strings.Contains(c.cypher, value) {
+			t.Errorf("expected %q to be a parameter, found it in the query text", value)
+		}
+	}
+	if countries, _ := c.params["countries"].([]string); strings.Join(countries, ",") != "BRA,ARG" {
+		t.Errorf("expected normalised countries BRA,ARG, got %v", c.params["countries"])
+	}
+	if !strings.Contains(c.cypher, "ORDER BY `deaths` DESC") {
+		t.Errorf("expected ORDER BY `deaths` DESC in query:\n%s", c.cypher)
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := map[string]Spec{
+		"no metrics":           {},
+		"unknown metric":       {Metrics: []string{"cases; MATCH (n) DETACH DELETE n"}},
+		"repeated metric":      {Metrics: []string{"cases", "cases"}},
+		"countries and region": {Metrics: []string{"cases"}, Countries: []string{"BRA"}, Region: "Europe"},
+		"invalid country":      {Metrics: []string{"cases"}, Countries: []string{"Brazil"}},
+		"invalid date":         {Metrics: []string{"cases"}, From: "01/01/2021"},
+		"from after to":        {Metrics: []string{"cases"}, From: "2021-02-01", To: "2021-01-01"},
+		"unknown aggregation":  {Metrics: []string{"cases"}, Aggregation: "median"},
+		"unknown group key":    {Metrics: []string{"cases"}, GroupBy: []string{"week"}},
+		"limit too large":      {Metrics: []string{"cases"}, Limit: maxLimit + 1},
+		"sort by non-column":   {Metrics: []string{"cases"}, GroupBy: []string{"country"}, Sort: []SortSpec{{Field: "date"}}},
+		"invalid sort order":   {Metrics: []string{"cases"}, Sort: []SortSpec{{Field: "cases", Order: "up"}}},
+	}
+	for name, spec := range tests {
+		if _, err := compile(spec); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHandleQuery_UnknownField(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"metrics": ["cases"], "country": "BRA"}`))
+	rec := httptest.NewRecorder()
+	HandleQuery(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleQuery_Route_Truncated(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/query", HandleQuery)
+
+	// Real ISO3 code and dates present in the test database
+	body := `{
+		"metrics": ["cases", "vaccinated"],
+		"countries": ["BRA"],
+		"from": "2021-07-01",
+		"to": "2021-07-31",
+		"limit": 5
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response QueryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Rows) != 5 || !response.Truncated {
+		t.Errorf("expected 5 rows and truncated=true, got %d rows and truncated=%v", len(response.Rows), response.Truncated)
+	}
+	if len(response.Rows) > 0 && response.Rows[0]["date"] != "2021-07-01" {
+		t.Errorf("expected first row on 2021-07-01, got %v", response.Rows[0]["date"])
+	}
+}
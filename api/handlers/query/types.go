@@ -0,0 +1,31 @@
+// Package query handles the generic declarative query endpoint.
+// Defines the query specification and the response data structures.
+//
+// A Spec selects metrics for a set of countries (or a region) over a date
+// range, optionally aggregated by group, sorted and limited. It is
+// validated and compiled into parameterised Cypher (see compile.go).
+
+package query
+
+type Spec struct {
+	Metrics     []string   `json:"metrics"`
+	Countries   []string   `json:"countries,omitempty"`
+	Region      string     `json:"region,omitempty"`
+	From        string     `json:"from,omitempty"`
+	To          string     `json:"to,omitempty"`
+	Aggregation string     `json:"aggregation,omitempty"`
+	GroupBy     []string   `json:"group_by,omitempty"`
+	Sort        []SortSpec `json:"sort,omitempty"`
+	Limit       int        `json:"limit,omitempty"`
+}
+
+type SortSpec struct {
+	Field string `json:"field"`
+	Order string `json:"order,omitempty"`
+}
+
+type QueryResponse struct {
+	Columns   []string                 `json:"columns" table:"columns"`
+	Rows      []map[string]interface{} `json:"rows" table:"rows"`
+	Truncated bool                     `json:"truncated"`
+}
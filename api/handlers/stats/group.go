@@ -0,0 +1,60 @@
+// Package stats serves the metrics declared in the registry.
+// This file builds the handlers of endpoints serving a group of metrics
+// stored on the same records (e.g. /hospital-stats), each with its own
+// response type.
+//
+// Not every country reports every metric of a group, so the responses keep
+// the values as pointers, omitted when missing, instead of reporting zeros.
+
+package stats
+
+import (
+	"net/http"
+
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// GroupHandler serves the metrics on a date, for the country in the URL,
+// the region in the query string or the whole world. It returns the
+// accumulated values unless only-news=true. The name prefixes the query
+// names (see Values) and respond builds the response from the parameters
+// and the values found.
+func GroupHandler(name string, ms []registry.Metric, respond func(p ValueParams, values map[string]float64) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var p ValueParams
+		if err := param.Parse(r, &p); err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		values, err := Values(r.Context(), name, ms, p.Scope(), string(p.Date), p.OnlyNews)
+		if err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		utils.Respond(w, r, respond(p, values))
+	}
+}
+
+// Count returns the value of a count metric, or nil when it has none.
+func Count(values map[string]float64, m registry.Metric) *int64 {
+	value, ok := values[m.Name]
+	if !ok {
+		return nil
+	}
+	count := int64(value)
+	return &count
+}
+
+// Rate returns the value of a metric as a number (e.g. a rate), or nil
+// when it has none.
+func Rate(values map[string]float64, m registry.Metric) *float64 {
+	value, ok := values[m.Name]
+	if !ok {
+		return nil
+	}
+	return &value
+}
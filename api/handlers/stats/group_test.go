@@ -0,0 +1,71 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/handlers/hospitalstats"
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/handlers/testingstats"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/go-chi/chi/v5"
+)
+
+// groups lists the controllers built with stats.GroupHandler.
+var groups = []struct {
+	base       string
+	controller http.HandlerFunc
+}{
+	{"/hospital-stats", hospitalstats.HospitalStatsController},
+	{"/testing-stats", testingstats.TestingStatsController},
+}
+
+func TestGroupHandler_Controllers(t *testing.T) {
+	future := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+	for _, group := range groups {
+		r := chi.NewRouter()
+		r.Get(group.base+"/{country}/{date}", group.controller)
+		r.Get(group.base+"/{date}", group.controller)
+
+		for path, status := range map[string]int{
+			"/invalid-date?only-news=true": http.StatusBadRequest,
+			"/FRA/" + future:               http.StatusNotFound,
+			// Real ISO3 code and date present in the test database
+			"/FRA/2021-07-31":                http.StatusOK,
+			"/FRA/2021-07-31?only-news=true": http.StatusOK,
+			"/2021-07-31":                    http.StatusOK,
+		} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, group.base+path, nil))
+			if rec.Code != status {
+				t.Errorf("%s%s: expected status %d, got %d", group.base, path, status, rec.Code)
+			}
+		}
+	}
+}
+
+func TestCountAndRate_OmitMissingValues(t *testing.T) {
+	tests, rate := registry.MustLookup("tests"), registry.MustLookup("positive-rate")
+	values := map[string]float64{tests.Name: 1200}
+
+	response := testingstats.TestingStatsResponse{
+		Tests:        stats.Count(values, tests),
+		PositiveRate: stats.Rate(values, rate),
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(body, &decoded)
+	if decoded["tests"] != float64(1200) {
+		t.Errorf("expected tests=1200, got %v", decoded["tests"])
+	}
+	if _, ok := decoded["positive_rate"]; ok {
+		t.Errorf("expected the missing positive rate to be omitted, got %s", body)
+	}
+}
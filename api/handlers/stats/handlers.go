@@ -0,0 +1,153 @@
+// Package stats serves the metrics declared in the registry.
+// This file builds the HTTP handlers bound to each metric: value on a date,
+// daily series and country ranking, plus the list of available metrics.
+//
+// The handlers are created once per metric when routes are registered
+// (see routes/stats.go), so a new registry entry gets every endpoint.
+
+package stats
+
+import (
+	"net/http"
+
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// ValueParams are the parameters of value endpoints, shared with the
+// covidstats, vaccination, hospitalstats and testingstats controllers:
+// an optional country in the path, a date and the region and only-news
+// query parameters.
+type ValueParams struct {
+	Country  param.Country `path:"country"`
+	Date     param.Date    `path:"date" required:"true" past:"true"`
+	Region   string        `query:"region"`
+	OnlyNews bool          `query:"only-news"`
+}
+
+// Scope returns the scope of the lookup.
+func (p ValueParams) Scope() Scope {
+	return Scope{Country: string(p.Country), Region: p.Region}
+}
+
+type seriesParams struct {
+	Country param.Country `path:"country"`
+	Region  string        `query:"region"`
+	param.Range
+}
+
+type rankingParams struct {
+	Date     param.Date `path:"date" required:"true" past:"true"`
+	Region   string     `query:"region"`
+	OnlyNews bool       `query:"only-news"`
+	Order    string     `query:"order" default:"desc" enum:"desc,asc"`
+	Limit    int        `query:"limit" default:"10" min:"1" max:"300"`
+}
+
+// ListMetrics lists the metrics served by the API.
+func ListMetrics(w http.ResponseWriter, r *http.Request) {
+	if err := param.Parse(r, &struct{}{}); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	response := MetricsResponse{Metrics: []MetricInfo{}}
+	for _, m := range registry.All() {
+		response.Metrics = append(response.Metrics, MetricInfo{
+			Name:        m.Name,
+			Description: m.Description,
+			Kind:        string(m.Kind),
+		})
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// ValueHandler serves the metric's value on a date, for the country in the
+// URL, the region in the query string or the whole world. It returns the
+// accumulated value unless only-news=true, and no value when the metric
+// has none on the date.
+func ValueHandler(m registry.Metric) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var p ValueParams
+		if err := param.Parse(r, &p); err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		scope := p.Scope()
+		values, err := Values(r.Context(), "stats."+m.Name, []registry.Metric{m}, scope, string(p.Date), p.OnlyNews)
+		if err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		response := ValueResponse{
+			Metric:   m.Name,
+			Country:  scope.Label(),
+			Date:     string(p.Date),
+			OnlyNews: p.OnlyNews,
+			Value:    Rate(values, m),
+		}
+
+		utils.Respond(w, r, response)
+	}
+}
+
+// SeriesHandler serves the metric's daily series between the from and to
+// query parameters (by default the whole pandemic up to today).
+func SeriesHandler(m registry.Metric) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var p seriesParams
+		if err := param.Parse(r, &p); err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		scope := Scope{Country: string(p.Country), Region: p.Region}
+		points, err := Series(r.Context(), m, scope, string(p.From), string(p.To))
+		if err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		response := SeriesResponse{
+			Metric:  m.Name,
+			Country: scope.Label(),
+			From:    string(p.From),
+			To:      string(p.To),
+			Points:  points,
+		}
+
+		utils.Respond(w, r, response)
+	}
+}
+
+// RankingHandler serves the countries ranked by the metric on a date.
+// Query parameters: only-news, region, order (desc or asc) and limit.
+func RankingHandler(m registry.Metric) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var p rankingParams
+		if err := param.Parse(r, &p); err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		entries, err := Ranking(r.Context(), m, string(p.Date), p.Region, p.OnlyNews, p.Order == "asc", p.Limit)
+		if err != nil {
+			utils.RespondWithError(w, err)
+			return
+		}
+
+		response := RankingResponse{
+			Metric:   m.Name,
+			Date:     string(p.Date),
+			OnlyNews: p.OnlyNews,
+			Region:   p.Region,
+			Entries:  entries,
+		}
+
+		utils.Respond(w, r, response)
+	}
+}
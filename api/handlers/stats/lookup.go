@@ -0,0 +1,536 @@
+// Package stats serves the metrics declared in the registry.
+// This file builds and runs the Cypher lookups shared by every metric:
+// a value on a date (accumulated or new), a daily series and a ranking
+// of countries.
+//
+// Country values are read from the per-country records, following the
+// metric's kind (cumulative or gauge). Region and worldwide values are
+// read from the precomputed daily aggregates, which hold both the accumulated
+// and the new value of every summable metric for every day.
+//
+// Values are float64, since some metrics are rates; counts stay exact.
+
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// Scope selects whose values are looked up: a country, a region or,
+// when both are empty, the whole world.
+type Scope struct {
+	Country string
+	Region  string
+}
+
+// Label names the scope in responses.
+func (s Scope) Label() string {
+	if s.Country != "" {
+		return s.Country
+	} else if s.Region != "" {
+		return s.Region
+	}
+	return "worldwide"
+}
+
+// kind names the scope in query names.
+func (s Scope) kind() string {
+	if s.Country != "" {
+		return "country"
+	} else if s.Region != "" {
+		return "region"
+	}
+	return "worldwide"
+}
+
+// aggregate returns the pattern matching the daily aggregate nodes of a
+// region or worldwide scope, bound to g.
+func (s Scope) aggregate() string {
+	if s.Region != "" {
+		return "(g:RegionDailyStats {region: $region})"
+	}
+	return "(g:GlobalDailyStats)"
+}
+
+func (s Scope) params() map[string]interface{} {
+	params := map[string]interface{}{}
+	if s.Country != "" {
+		params["country"] = s.Country
+	} else if s.Region != "" {
+		params["region"] = s.Region
+	}
+	return params
+}
+
+// missing explains an empty lookup: the country or region of the scope is
+// unknown, or it has no data for the input.
+func missing(ctx context.Context, scope Scope, detail string) *utils.Problem {
+	switch {
+	case scope.Country != "":
+		known, err := neo4j.KnownCountries(ctx, []string{scope.Country})
+		if err != nil {
+			return utils.DatabaseError()
+		}
+		if !known[scope.Country] {
+			return utils.UnknownCountry(scope.Country)
+		}
+	case scope.Region != "":
+		known, err := neo4j.KnownRegion(ctx, scope.Region)
+		if err != nil {
+			return utils.DatabaseError()
+		}
+		if !known {
+			return utils.NewProblem(utils.CodeUnknownRegion, "No country found in region "+scope.Region).WithParam("region")
+		}
+	}
+	return utils.NewProblem(utils.CodeNoData, detail)
+}
+
+// Values looks up metrics on a date for a scope: the accumulated values on
+// or before the date, or the new values of that day when onlyNews is set.
+// The date is expected to be validated already (see ValueParams).
+// The metrics must be stored on the same per-country records, so that they
+// are read by a single query.
+// The name prefixes the query name in metrics and logs (e.g. "covidstats"
+// gives "covidstats.accumulated.country").
+//
+// Metrics without a value (null, or not aggregated for a region or
+// worldwide scope) are missing from the returned map.
+func Values(ctx context.Context, name string, ms []registry.Metric, scope Scope, date string, onlyNews bool) (map[string]float64, error) {
+	for _, m := range ms[1:] {
+		if m.Label != ms[0].Label {
+			return nil, fmt.Errorf("metrics %s and %s are not stored on the same records", ms[0].Name, m.Name)
+		}
+	}
+	if scope.Country == "" {
+		ms = aggregated(ms)
+		if len(ms) == 0 {
+			return nil, utils.InvalidParam("region", "This metric is only available per country")
+		}
+	}
+
+	variant := "accumulated"
+	if onlyNews {
+		variant = "new"
+	}
+
+	params := scope.params()
+	params["date"] = date
+
+	records, err := neo4j.Query(ctx, name+"."+variant+"."+scope.kind(), ValueQuery(ms, scope, onlyNews), params)
+	if err != nil {
+		return nil, utils.DatabaseError()
+	}
+	if len(records) == 0 {
+		if onlyNews {
+			return nil, missing(ctx, scope, "No data found for the current date")
+		}
+		return nil, missing(ctx, scope, "No data found for the given input")
+	}
+
+	values := make(map[string]float64, len(ms))
+	for _, m := range ms {
+		raw, _ := records[0].Get(m.Name)
+		if value, ok := number(raw); ok {
+			values[m.Name] = value
+		}
+	}
+	return values, nil
+}
+
+// ValueQuery returns the query run by Values. It reads $country or $region,
+// following the scope, and $date, and returns a column per metric.
+func ValueQuery(ms []registry.Metric, scope Scope, onlyNews bool) string {
+	switch {
+	case scope.Country != "" && onlyNews:
+		return countryNewQuery(ms, paramInput)
+	case scope.Country != "":
+		return countryAccumulatedQuery(ms, paramInput)
+	case onlyNews:
+		return aggregateNewQuery(scope, ms)
+	default:
+		return aggregateAccumulatedQuery(scope, ms)
+	}
+}
+
+// BatchQuery returns the country query of ValueQuery for many lookups at
+// once. It reads $items, a list of {index, country, date} maps, and returns
+// an index column and a column per metric. Items without a record have no
+// row.
+func BatchQuery(ms []registry.Metric, onlyNews bool) string {
+	query := countryAccumulatedQuery(ms, itemInput)
+	if onlyNews {
+		query = countryNewQuery(ms, itemInput)
+	}
+	return fmt.Sprintf(`
+		UNWIND $items AS item
+		CALL {
+			WITH item
+			%s
+		}
+		RETURN item.index AS index, %s
+	`, query, columns(ms))
+}
+
+// input names where country lookups read the country and the date from:
+// the $country and $date parameters, or the fields of a batch item, which
+// subqueries must then import along with the record.
+type input struct {
+	country, date string
+	imports       string
+}
+
+var (
+	paramInput = input{country: "$country", date: "$date"}
+	itemInput  = input{country: "item.country", date: "item.date", imports: ", item"}
+)
+
+// aggregated keeps the metrics that have region and worldwide values.
+func aggregated(ms []registry.Metric) []registry.Metric {
+	var kept []registry.Metric
+	for _, m := range ms {
+		if m.Aggregated() {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// number converts a numeric Neo4j value.
+func number(raw interface{}) (float64, bool) {
+	switch value := raw.(type) {
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+// countryAccumulatedQuery returns the accumulated values of a country on or
+// before the date: for each metric, the latest record on or before the date
+// holding it (see latestRecords). Countries with none of the metrics on or
+// before the date have no row.
+func countryAccumulatedQuery(ms []registry.Metric, in input) string {
+	found := make([]string, len(ms))
+	returns := make([]string, len(ms))
+	for i, m := range ms {
+		found[i] = record("s", i) + " IS NOT NULL"
+		returns[i] = fmt.Sprintf("%s.%s AS %s", record("s", i), m.Property, quote(m.Name))
+	}
+	return fmt.Sprintf(`
+		%s
+		WITH %s
+		WHERE %s
+		RETURN %s
+	`, latestRecords("(:Country {iso3: "+in.country+"})", in, ms), records("s", len(ms)),
+		strings.Join(found, " OR "), strings.Join(returns, ", "))
+}
+
+// countryNewQuery returns the new values of a country on the date, found
+// through the (country, date) index. Cumulative values are differences to
+// the previous record holding the same metric, which is usually one NEXT hop
+// away (see previousRecords), so the record of the date must hold at least
+// one of them; gauge values are read as stored.
+func countryNewQuery(ms []registry.Metric, in input) string {
+	var cumulative []registry.Metric
+	returns := make([]string, len(ms))
+	for i, m := range ms {
+		if m.Kind == registry.Cumulative {
+			returns[i] = fmt.Sprintf("s.%[1]s - coalesce(%[2]s.%[1]s, 0) AS %[3]s", m.Property, record("before", len(cumulative)), quote(m.Name))
+			cumulative = append(cumulative, m)
+		} else {
+			returns[i] = fmt.Sprintf("s.%s AS %s", m.Property, quote(m.Name))
+		}
+	}
+
+	where, previous := "", ""
+	if len(cumulative) > 0 {
+		where = "WHERE " + held(cumulative, "s") + " IS NOT NULL"
+		previous = previousRecords(cumulative)
+	}
+	return fmt.Sprintf(`
+		MATCH (s:%s {country: %s, date: date(%s)})
+		%s
+		%s
+		RETURN %s
+	`, ms[0].Label, in.country, in.date, where, previous, strings.Join(returns, ", "))
+}
+
+// record names the variable bound to the record (or value) of the i-th
+// metric.
+func record(prefix string, i int) string {
+	return fmt.Sprintf("%s%d", prefix, i)
+}
+
+// records lists the variables of n metrics (see record).
+func records(prefix string, n int) string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = record(prefix, i)
+	}
+	return strings.Join(names, ", ")
+}
+
+// latestRecords binds s0, s1, ... to the latest record of the country
+// matched by the pattern, on or before the date, that holds each metric, or
+// to null when there is none. Metrics may be reported on different days, so
+// each one is resolved on its own. The country's LATEST pointer is followed
+// when that record qualifies; otherwise the (country, date) index is used.
+func latestRecords(country string, in input, ms []registry.Metric) string {
+	parts := []string{fmt.Sprintf("MATCH %s-[:%s]->(latest:%s)", country, ms[0].Latest, ms[0].Label)}
+	for i, m := range ms {
+		parts = append(parts, fmt.Sprintf(`CALL {
+			WITH latest%[4]s
+			CALL {
+				WITH latest%[4]s
+				WITH latest%[4]s WHERE latest.date <= date(%[5]s) AND latest.%[2]s IS NOT NULL
+				RETURN latest AS %[3]s
+				UNION
+				WITH latest%[4]s
+				WITH latest%[4]s WHERE latest.date > date(%[5]s) OR latest.%[2]s IS NULL
+				MATCH (%[3]s:%[1]s {country: latest.country})
+				WHERE %[3]s.date <= date(%[5]s) AND %[3]s.%[2]s IS NOT NULL
+				RETURN %[3]s ORDER BY %[3]s.date DESC LIMIT 1
+			}
+			RETURN collect(%[3]s)[0] AS %[3]s
+		}`, m.Label, m.Property, record("s", i), in.imports, in.date))
+	}
+	return strings.Join(parts, "\n\t\t")
+}
+
+// previousRecords binds before0, before1, ... to the record preceding s that
+// holds each metric, or to null when there is none. Series reported on
+// different days (e.g. booster doses) may skip the record one NEXT hop away,
+// in which case the (country, date) index is used.
+func previousRecords(ms []registry.Metric) string {
+	parts := []string{fmt.Sprintf("OPTIONAL MATCH (prev:%s)-[:NEXT]->(s)", ms[0].Label)}
+	for i, m := range ms {
+		parts = append(parts, fmt.Sprintf(`CALL {
+			WITH prev
+			WITH prev WHERE prev IS NULL OR prev.%[2]s IS NOT NULL
+			RETURN prev AS %[3]s
+			UNION
+			WITH s, prev
+			WITH s, prev WHERE prev IS NOT NULL AND prev.%[2]s IS NULL
+			OPTIONAL MATCH (%[3]s:%[1]s {country: s.country})
+			WHERE %[3]s.date < s.date AND %[3]s.%[2]s IS NOT NULL
+			RETURN %[3]s ORDER BY %[3]s.date DESC LIMIT 1
+		}`, m.Label, m.Property, record("before", i)))
+	}
+	return strings.Join(parts, "\n\t\t")
+}
+
+// held returns an expression that is null when the record bound to the
+// variable holds none of the metrics.
+func held(ms []registry.Metric, variable string) string {
+	if len(ms) == 1 {
+		return variable + "." + ms[0].Property
+	}
+	parts := make([]string, len(ms))
+	for i, m := range ms {
+		parts[i] = variable + "." + m.Property
+	}
+	return "coalesce(" + strings.Join(parts, ", ") + ")"
+}
+
+// aggregateNewQuery returns the new values held by the daily aggregate of
+// the date.
+func aggregateNewQuery(scope Scope, ms []registry.Metric) string {
+	returns := make([]string, len(ms))
+	for i, m := range ms {
+		returns[i] = fmt.Sprintf("g.%s AS %s", m.AggregateNew, quote(m.Name))
+	}
+	return fmt.Sprintf(`
+		MATCH %s
+		WHERE g.date = date($date)
+		RETURN %s
+	`, scope.aggregate(), strings.Join(returns, ", "))
+}
+
+// aggregateAccumulatedQuery returns, for each metric, the accumulated value
+// of the latest daily aggregate on or before the date that holds it. Gauge
+// readings are not carried forward to days nobody reported, so each metric
+// may come from a different day. Scopes with none of the metrics on or
+// before the date have no row.
+func aggregateAccumulatedQuery(scope Scope, ms []registry.Metric) string {
+	calls := make([]string, len(ms))
+	found := make([]string, len(ms))
+	returns := make([]string, len(ms))
+	for i, m := range ms {
+		v := record("v", i)
+		calls[i] = fmt.Sprintf(`CALL {
+			MATCH %[1]s
+			WHERE g.date <= date($date) AND g.%[2]s IS NOT NULL
+			WITH g ORDER BY g.date DESC LIMIT 1
+			RETURN collect(g.%[2]s)[0] AS %[3]s
+		}`, scope.aggregate(), m.AggregateTotal, v)
+		found[i] = v + " IS NOT NULL"
+		returns[i] = v + " AS " + quote(m.Name)
+	}
+	return fmt.Sprintf(`
+		%s
+		WITH %s
+		WHERE %s
+		RETURN %s
+	`, strings.Join(calls, "\n\t\t"), records("v", len(ms)), strings.Join(found, " OR "), strings.Join(returns, ", "))
+}
+
+// Series looks up the daily accumulated and new values of a metric for a
+// scope between two dates (inclusive), ordered by date. For gauge metrics
+// both values are the reading of the day.
+func Series(ctx context.Context, m registry.Metric, scope Scope, from, to string) ([]Point, error) {
+	if scope.Country == "" && !m.Aggregated() {
+		return nil, utils.InvalidParam("region", "This metric is only available per country")
+	}
+	params := scope.params()
+	params["from"], params["to"] = from, to
+
+	var query string
+	if scope.Country != "" {
+		// Differences of cumulative metrics need the earlier records too;
+		// they are computed below.
+		query = fmt.Sprintf(`
+			MATCH (s:%s {country: $country})
+			WHERE s.date <= date($to) AND s.%[2]s IS NOT NULL
+			RETURN toString(s.date) AS date, s.%[2]s AS value
+			ORDER BY date
+		`, m.Label, m.Property)
+	} else {
+		query = fmt.Sprintf(`
+			MATCH %s
+			WHERE g.date >= date($from) AND g.date <= date($to)
+			RETURN toString(g.date) AS date, g.%s AS total, g.%s AS new
+			ORDER BY date
+		`, scope.aggregate(), m.AggregateTotal, m.AggregateNew)
+	}
+
+	records, err := neo4j.Query(ctx, "stats."+m.Name+".series."+scope.kind(), query, params)
+	if err != nil {
+		return nil, utils.DatabaseError()
+	}
+	if len(records) == 0 && scope != (Scope{}) {
+		// An empty series is valid, unless the country or region is unknown.
+		if p := missing(ctx, scope, ""); p.Code != utils.CodeNoData {
+			return nil, p
+		}
+	}
+
+	points := make([]Point, 0, len(records))
+	var previous float64
+	for _, record := range records {
+		dateRaw, _ := record.Get("date")
+		point := Point{}
+		point.Date, _ = dateRaw.(string)
+
+		if scope.Country == "" {
+			totalRaw, _ := record.Get("total")
+			newRaw, _ := record.Get("new")
+			point.Total, _ = number(totalRaw)
+			point.New, _ = number(newRaw)
+			points = append(points, point)
+			continue
+		}
+
+		valueRaw, _ := record.Get("value")
+		value, _ := number(valueRaw)
+		if m.Kind == registry.Gauge {
+			point.Total, point.New = value, value
+		} else {
+			point.Total, point.New = value, value-previous
+		}
+		previous = point.Total
+		if point.Date >= from {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+// Ranking looks up the countries with the highest (or, when ascending, the
+// lowest) values of a metric on a date, optionally within a region.
+func Ranking(ctx context.Context, m registry.Metric, date, region string, onlyNews, ascending bool, limit int) ([]RankingEntry, error) {
+	ms := []registry.Metric{m}
+
+	where := ""
+	params := map[string]interface{}{"date": date, "limit": limit}
+	if region != "" {
+		where = "WHERE c.region = $region"
+		params["region"] = region
+	}
+
+	var match, value string
+	switch {
+	case onlyNews && m.Kind != registry.Cumulative:
+		match = fmt.Sprintf("MATCH (s:%s {country: c.iso3, date: date($date)})", m.Label)
+		value = "s." + m.Property
+	case onlyNews:
+		match = fmt.Sprintf(`MATCH (s:%s {country: c.iso3, date: date($date)})
+			%s`, m.Label, previousRecords(ms))
+		value = fmt.Sprintf("s.%[1]s - coalesce(before0.%[1]s, 0)", m.Property)
+	default:
+		match = latestRecords("(c)", paramInput, ms)
+		value = "s0." + m.Property
+	}
+
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+	query := fmt.Sprintf(`
+		MATCH (c:Country)
+		%s
+		%s
+		WITH c, %s AS value
+		WHERE value IS NOT NULL
+		RETURN c.iso3 AS country, value
+		ORDER BY value %s, country
+		LIMIT $limit
+	`, where, match, value, order)
+
+	variant := "accumulated"
+	if onlyNews {
+		variant = "new"
+	}
+	records, err := neo4j.Query(ctx, "stats."+m.Name+".ranking."+variant, query, params)
+	if err != nil {
+		return nil, utils.DatabaseError()
+	}
+	if len(records) == 0 && region != "" {
+		if p := missing(ctx, Scope{Region: region}, ""); p.Code != utils.CodeNoData {
+			return nil, p
+		}
+	}
+
+	entries := make([]RankingEntry, 0, len(records))
+	for i, record := range records {
+		countryRaw, _ := record.Get("country")
+		valueRaw, _ := record.Get("value")
+		entry := RankingEntry{Rank: i + 1}
+		entry.Country, _ = countryRaw.(string)
+		entry.Value, _ = number(valueRaw)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// columns lists the metric columns, for queries that project them twice.
+func columns(ms []registry.Metric) string {
+	parts := make([]string, len(ms))
+	for i, m := range ms {
+		parts[i] = quote(m.Name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// quote escapes a metric name for use as a Cypher alias, since names may
+// contain dashes (e.g. "fully-vaccinated").
+func quote(name string) string {
+	return "`" + name + "`"
+}
@@ -0,0 +1,213 @@
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func newRouter() *chi.Mux {
+	r := chi.NewRouter()
+	m := registry.MustLookup("cases")
+	r.Get("/stats/cases/{date}", ValueHandler(m))
+	r.Get("/stats/cases/{country}/{date}", ValueHandler(m))
+	r.Get("/stats/cases/series/{country}", SeriesHandler(m))
+	r.Get("/stats/cases/ranking/{date}", RankingHandler(m))
+	return r
+}
+
+func TestValueHandler_InvalidDate(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/bad-date", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if p := decodeProblem(t, rec); p.Code != utils.CodeInvalidParameter || p.Param != "date" {
+		t.Errorf("expected an invalid-parameter problem for date, got %+v", p)
+	}
+}
+
+func TestValueHandler_FutureDate(t *testing.T) {
+	future := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/BRA/"+future, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for future date, got %d", http.StatusNotFound, rec.Code)
+	}
+	if p := decodeProblem(t, rec); p.Code != utils.CodeFutureDate {
+		t.Errorf("expected a future-date problem, got %+v", p)
+	}
+}
+
+func TestValueHandler_UnknownCountryOrNoData(t *testing.T) {
+	for target, code := range map[string]string{
+		"/stats/cases/XYZ/2021-07-31": utils.CodeUnknownCountry,
+		"/stats/cases/BRA/2020-01-01": utils.CodeNoData,
+	} {
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusNotFound, rec.Code)
+		}
+		if p := decodeProblem(t, rec); p.Code != code {
+			t.Errorf("%s: expected a %s problem, got %+v", target, code, p)
+		}
+	}
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) utils.Problem {
+	t.Helper()
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("expected application/problem+json, got %q", got)
+	}
+	var p utils.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	return p
+}
+
+func TestSeriesHandler_InvalidRange(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/series/BRA?from=2021-02-01&to=2021-01-01", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestRankingHandler_InvalidLimit(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/ranking/2021-07-31?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestValueHandler_NotAggregated(t *testing.T) {
+	r := chi.NewRouter()
+	m := registry.MustLookup("positive-rate")
+	r.Get("/stats/positive-rate/{date}", ValueHandler(m))
+	r.Get("/stats/positive-rate/series", SeriesHandler(m))
+
+	for _, path := range []string{"/stats/positive-rate/2021-07-31", "/stats/positive-rate/series?region=Europe"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestValueHandler_PositiveCountry(t *testing.T) {
+	// Real ISO3 code and date present in the test database
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/BRA/2021-07-31?only-news=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d for country new cases, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestSeriesHandler_PositiveCountry(t *testing.T) {
+	// Real ISO3 code and dates present in the test database
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/series/BRA?from=2021-07-01&to=2021-07-31", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response SeriesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for i := 1; i < len(response.Points); i++ {
+		if response.Points[i].Date <= response.Points[i-1].Date {
+			t.Fatalf("expected points ordered by date, got %s after %s", response.Points[i].Date, response.Points[i-1].Date)
+		}
+	}
+}
+
+func TestRankingHandler_Positive(t *testing.T) {
+	// Date present in the test database
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/cases/ranking/2021-07-31?limit=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response RankingResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Entries) > 5 {
+		t.Errorf("expected at most 5 entries, got %d", len(response.Entries))
+	}
+	for i := 1; i < len(response.Entries); i++ {
+		if response.Entries[i].Value > response.Entries[i-1].Value {
+			t.Errorf("expected descending values, got %v after %v", response.Entries[i].Value, response.Entries[i-1].Value)
+		}
+	}
+}
+
+func TestBatchQuery_ReadsItems(t *testing.T) {
+	ms := []registry.Metric{registry.MustLookup("vaccinated")}
+	for _, onlyNews := range []bool{false, true} {
+		query := BatchQuery(ms, onlyNews)
+		if strings.Contains(query, "$country") || strings.Contains(query, "$date") {
+			t.Errorf("only-news=%v: expected the items to replace the parameters:\n%s", onlyNews, query)
+		}
+		for _, part := range []string{"UNWIND $items AS item", "item.country", "date(item.date)", "item.index AS index", "`vaccinated`"} {
+			if !strings.Contains(query, part) {
+				t.Errorf("only-news=%v: expected %q in:\n%s", onlyNews, part, query)
+			}
+		}
+	}
+	// Records without the value are skipped, as in single lookups.
+	for _, onlyNews := range []bool{false, true} {
+		query := BatchQuery(ms, onlyNews)
+		if !strings.Contains(query, "s0.totalVaccinated IS NOT NULL") && !strings.Contains(query, "before0.totalVaccinated IS NOT NULL") {
+			t.Errorf("only-news=%v: expected records without totalVaccinated to be skipped:\n%s", onlyNews, query)
+		}
+	}
+}
+
+func TestValueQuery_ResolvesRecordsPerMetric(t *testing.T) {
+	ms := []registry.Metric{registry.MustLookup("cases"), registry.MustLookup("deaths")}
+	scope := Scope{Country: "BRA"}
+
+	// Cases and deaths may be reported on different days, so each one is
+	// read from the latest record holding it.
+	accumulated := ValueQuery(ms, scope, false)
+	for _, part := range []string{"s0.totalCases IS NOT NULL", "s1.totalDeaths IS NOT NULL", "s0.totalCases AS `cases`", "s1.totalDeaths AS `deaths`"} {
+		if !strings.Contains(accumulated, part) {
+			t.Errorf("expected %q in:\n%s", part, accumulated)
+		}
+	}
+
+	// New values are differences to the previous record holding the same metric.
+	news := ValueQuery(ms, scope, true)
+	for _, part := range []string{"before0.totalCases IS NOT NULL", "before1.totalDeaths IS NOT NULL", "coalesce(before1.totalDeaths, 0) AS `deaths`"} {
+		if !strings.Contains(news, part) {
+			t.Errorf("expected %q in:\n%s", part, news)
+		}
+	}
+}
+
+func TestValueQuery_AggregatesPerMetric(t *testing.T) {
+	ms := []registry.Metric{registry.MustLookup("hospital-patients"), registry.MustLookup("icu-patients")}
+
+	// Readings are not carried forward, so each one is read from the latest
+	// aggregate holding it.
+	query := ValueQuery(ms, Scope{Region: "Europe"}, false)
+	for _, m := range ms {
+		if part := "g." + m.AggregateTotal + " IS NOT NULL"; !strings.Contains(query, part) {
+			t.Errorf("expected %q in:\n%s", part, query)
+		}
+	}
+}
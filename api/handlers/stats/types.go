@@ -0,0 +1,52 @@
+// Package stats serves the metrics declared in the registry.
+// Defines the response data structures of the generated endpoints.
+
+package stats
+
+// ValueResponse omits the value when the metric has none on the date
+// (e.g. a reading nobody reported).
+type ValueResponse struct {
+	Metric   string   `json:"metric"`
+	Country  string   `json:"country"`
+	Date     string   `json:"date"`
+	OnlyNews bool     `json:"only_news"`
+	Value    *float64 `json:"value,omitempty"`
+}
+
+type Point struct {
+	Date  string  `json:"date"`
+	Total float64 `json:"total"`
+	New   float64 `json:"new"`
+}
+
+type SeriesResponse struct {
+	Metric  string  `json:"metric"`
+	Country string  `json:"country"`
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Points  []Point `json:"points" table:"rows"`
+}
+
+type RankingEntry struct {
+	Rank    int     `json:"rank"`
+	Country string  `json:"country"`
+	Value   float64 `json:"value"`
+}
+
+type RankingResponse struct {
+	Metric   string         `json:"metric"`
+	Date     string         `json:"date"`
+	OnlyNews bool           `json:"only_news"`
+	Region   string         `json:"region,omitempty"`
+	Entries  []RankingEntry `json:"entries" table:"rows"`
+}
+
+type MetricInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Kind        string `json:"kind"`
+}
+
+type MetricsResponse struct {
+	Metrics []MetricInfo `json:"metrics" table:"rows"`
+}
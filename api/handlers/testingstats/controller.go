@@ -0,0 +1,35 @@
+// Package testingstats handles COVID-19 testing statistics.
+// This file binds the /testing-stats endpoints to their metrics.
+//
+// It supports both accumulated and daily data, at country, region or global level.
+// Tests are running totals, like cases; the positive rate is a reading that
+// cannot be summed over countries, so it is only returned for a country.
+
+package testingstats
+
+import (
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/registry"
+)
+
+var (
+	tests        = registry.MustLookup("tests")
+	positiveRate = registry.MustLookup("positive-rate")
+)
+
+// TestingStatsController responds with the last known tests and positive
+// rate *on or before* the date or, with only-news, the tests performed on
+// the date, i.e. the difference to the most recent prior date, and the
+// positive rate reported on the date. Values without a report are omitted.
+var TestingStatsController = stats.GroupHandler("testingstats",
+	[]registry.Metric{tests, positiveRate},
+	func(p stats.ValueParams, values map[string]float64) interface{} {
+		return TestingStatsResponse{
+			Country:      p.Scope().Label(),
+			Date:         string(p.Date),
+			OnlyNews:     p.OnlyNews,
+			Tests:        stats.Count(values, tests),
+			PositiveRate: stats.Rate(values, positiveRate),
+		}
+	},
+)
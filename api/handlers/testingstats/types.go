@@ -0,0 +1,17 @@
+// Package testingstats handles COVID-19 testing statistics.
+// Defines response data structures used across testing statistics handlers.
+
+package testingstats
+
+type TestingStatsResponse struct {
+	Country  string `json:"country"`
+	Date     string `json:"date"`
+	OnlyNews bool   `json:"only_news"`
+
+	// Tests is missing when no total was reported.
+	Tests *int64 `json:"tests,omitempty"`
+
+	// PositiveRate is only available per country, and missing when the
+	// country did not report it.
+	PositiveRate *float64 `json:"positive_rate,omitempty"`
+}
@@ -1,25 +1,70 @@
 // Package vaccination handles COVID-19 vaccination statistics.
 // This file routes incoming HTTP requests to the appropriate handler based on the URL and query parameters.
 //
-// It supports both accumulated and daily data, at country or global level.
+// It supports both accumulated and daily data, at country, region or global level,
+// for each dose series selected by the `dose` parameter.
+// The values are looked up through the metric registry (see handlers/stats).
 
 package vaccination
 
 import (
 	"net/http"
-	"strings"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/registry"
+	"github.com/biiafranca/viralgraph/api/utils"
 )
 
+// DoseValues lists the values of the `dose` parameter, as in the enum tag
+// of vaccinationParams, for callers reading the dose from elsewhere (e.g.
+// batch items).
+const DoseValues = "first,full,booster,all-doses"
+
+// Doses maps the values of the `dose` parameter to their metrics:
+// people with at least one dose, people fully vaccinated, booster doses
+// and doses administered of any kind.
+var Doses = map[string]registry.Metric{
+	"first":     registry.MustLookup("vaccinated"),
+	"full":      registry.MustLookup("fully-vaccinated"),
+	"booster":   registry.MustLookup("boosters"),
+	"all-doses": registry.MustLookup("doses"),
+}
+
+type vaccinationParams struct {
+	stats.ValueParams
+	Dose string `query:"dose" default:"first" enum:"first,full,booster,all-doses"`
+}
+
 func VaccinationController(w http.ResponseWriter, r *http.Request) {
-	country := chi.URLParam(r, "country")
-	date := chi.URLParam(r, "date")
-	onlyNews := strings.ToLower(r.URL.Query().Get("only-news")) == "true"
-
-	if onlyNews {
-		handleNew(w, country, date)
-	} else {
-		handleAccumulated(w, country, date)
+	var p vaccinationParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	respond(w, r, p)
+}
+
+// respond responds with the last known total of the dose series *on or
+// before* the date or, with only-news, the new vaccinations of the dose
+// series on the date, i.e. the difference to the most recent prior record.
+func respond(w http.ResponseWriter, r *http.Request, p vaccinationParams) {
+	scope, date, dose, onlyNews := p.Scope(), string(p.Date), p.Dose, p.OnlyNews
+	m := Doses[dose]
+	values, err := stats.Values(r.Context(), "vaccination."+dose, []registry.Metric{m}, scope, date, onlyNews)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
 	}
+
+	response := VaccinationResponse{
+		Country:         scope.Label(),
+		Date:            date,
+		OnlyNews:        onlyNews,
+		Dose:            dose,
+		TotalVaccinated: int64(values[m.Name]),
+	}
+
+	utils.Respond(w, r, response)
 }
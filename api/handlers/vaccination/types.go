@@ -7,5 +7,6 @@ type VaccinationResponse struct {
 	Country         string `json:"country"`
 	Date            string `json:"date"`
 	OnlyNews        bool   `json:"only_news"`
+	Dose            string `json:"dose"`
 	TotalVaccinated int64  `json:"total_vaccinated"`
 }
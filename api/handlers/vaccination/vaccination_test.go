@@ -3,15 +3,27 @@ package vaccination
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func TestHandleNew_InvalidDate(t *testing.T) {
+// serve sends a GET request for path to the controller through its routes.
+func serve(path string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/vaccinations/{country}/{date}", VaccinationController)
+	r.Get("/vaccinations/{date}", VaccinationController)
+
 	rec := httptest.NewRecorder()
-	handleNew(rec, "", "invalid-date")
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestHandleNew_InvalidDate(t *testing.T) {
+	rec := serve("/vaccinations/invalid-date?only-news=true")
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
 	}
@@ -19,8 +31,7 @@ func TestHandleNew_InvalidDate(t *testing.T) {
 
 func TestHandleNew_FutureDate(t *testing.T) {
 	future := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
-	rec := httptest.NewRecorder()
-	handleNew(rec, "", future)
+	rec := serve("/vaccinations/" + future + "?only-news=true")
 	if rec.Code != http.StatusNotFound {
 		t.Errorf("expected status %d for future date, got %d", http.StatusNotFound, rec.Code)
 	}
@@ -29,8 +40,7 @@ func TestHandleNew_FutureDate(t *testing.T) {
 func TestHandleNew_PositiveGlobal(t *testing.T) {
 	// Date present in the test database
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleNew(rec, "", date)
+	rec := serve("/vaccinations/" + date + "?only-news=true")
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for valid global stats, got %d", http.StatusOK, rec.Code)
 	}
@@ -40,16 +50,14 @@ func TestHandleNew_PositiveCountry(t *testing.T) {
 	// Real ISO3 code and date present in the test database
 	country := "BRA"
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleNew(rec, country, date)
+	rec := serve("/vaccinations/" + country + "/" + date + "?only-news=true")
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for valid country stats, got %d", http.StatusOK, rec.Code)
 	}
 }
 
 func TestHandleAccumulated_InvalidDate(t *testing.T) {
-	rec := httptest.NewRecorder()
-	handleAccumulated(rec, "", "bad-date")
+	rec := serve("/vaccinations/bad-date")
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d for invalid date, got %d", http.StatusBadRequest, rec.Code)
 	}
@@ -58,8 +66,7 @@ func TestHandleAccumulated_InvalidDate(t *testing.T) {
 func TestHandleAccumulated_PositiveGlobal(t *testing.T) {
 	// Date present in the test database
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleAccumulated(rec, "", date)
+	rec := serve("/vaccinations/" + date)
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for global accumulated, got %d", http.StatusOK, rec.Code)
 	}
@@ -69,8 +76,7 @@ func TestHandleAccumulated_PositiveCountry(t *testing.T) {
 	// Real ISO3 code and date present in the test database
 	country := "BRA"
 	date := "2021-07-31"
-	rec := httptest.NewRecorder()
-	handleAccumulated(rec, country, date)
+	rec := serve("/vaccinations/" + country + "/" + date)
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d for country accumulated, got %d", http.StatusOK, rec.Code)
 	}
@@ -82,18 +88,62 @@ func TestVaccinationController_Routes(t *testing.T) {
 	r.Get("/vaccinations/{date}", VaccinationController)
 
 	// Test global route
-	req1 := httptest.NewRequest(http.MethodGet, "/vaccinations/2021-07-31?onlyNews=false", nil)
+	req1 := httptest.NewRequest(http.MethodGet, "/vaccinations/2021-07-31?only-news=false", nil)
 	rec1 := httptest.NewRecorder()
 	r.ServeHTTP(rec1, req1)
 	if rec1.Code != http.StatusOK {
 		t.Errorf("expected status %d for global accumulated, got %d", http.StatusOK, rec1.Code)
 	}
 
-	// Test country route with onlyNews=true
-	req2 := httptest.NewRequest(http.MethodGet, "/vaccinations/BRA/2021-07-31?onlyNews=true", nil)
+	// Test country route with only-news=true
+	req2 := httptest.NewRequest(http.MethodGet, "/vaccinations/BRA/2021-07-31?only-news=true", nil)
 	rec2 := httptest.NewRecorder()
 	r.ServeHTTP(rec2, req2)
 	if rec2.Code != http.StatusOK {
 		t.Errorf("expected status %d for country new data, got %d", http.StatusOK, rec2.Code)
 	}
 }
+
+func TestVaccinationController_InvalidDose(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccination/{date}", VaccinationController)
+
+	req := httptest.NewRequest(http.MethodGet, "/vaccination/2021-07-31?dose=second", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for invalid dose, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestVaccinationController_Doses(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccination/{country}/{date}", VaccinationController)
+	r.Get("/vaccination/{date}", VaccinationController)
+
+	// Real ISO3 code and date present in the test database
+	for _, path := range []string{
+		"/vaccination/BRA/2021-12-31?dose=full",
+		"/vaccination/BRA/2021-12-31?dose=booster&only-news=true",
+		"/vaccination/2021-12-31?dose=all-doses",
+		"/vaccination/2021-12-31?dose=full&only-news=true",
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusOK, rec.Code)
+		}
+	}
+}
+
+func TestDoseValues_MatchParams(t *testing.T) {
+	field, _ := reflect.TypeOf(vaccinationParams{}).FieldByName("Dose")
+	if enum := field.Tag.Get("enum"); enum != DoseValues {
+		t.Errorf("expected the dose enum %q, got %q", DoseValues, enum)
+	}
+	for _, dose := range strings.Split(DoseValues, ",") {
+		if _, ok := Doses[dose]; !ok {
+			t.Errorf("dose %q has no metric", dose)
+		}
+	}
+}
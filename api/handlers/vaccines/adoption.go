@@ -0,0 +1,150 @@
+// Package vaccines handles COVID-19 vaccines information.
+//
+// Returns how a vaccine (id or slug) spread: the cumulative number of
+// adopting countries over time, each country's adoption lag relative to the
+// vaccine's first global use, and the same broken down by region.
+
+package vaccines
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+func HandleAdoption(w http.ResponseWriter, r *http.Request) {
+	var p vaccinePath
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	property, vaccine, err := vaccineRef(p.VaccineID)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+
+	query := fmt.Sprintf(`
+		MATCH (v:Vaccine {%s: $vaccine})
+		OPTIONAL MATCH (c:Country)-[r:USES]->(v)
+		WHERE r.first_used IS NOT NULL
+		RETURN v.name AS name, toString(v.first_global_use) AS first_global_use,
+			c.iso3 AS country, c.region AS region, toString(r.first_used) AS first_used
+		ORDER BY first_used, country
+	`, property)
+	params := map[string]interface{}{"vaccine": vaccine}
+
+	records, err := neo4j.Query(r.Context(), "vaccines.adoption."+property, query, params)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	if len(records) == 0 {
+		utils.RespondWithError(w, unknownVaccine())
+		return
+	}
+
+	name, _ := records[0].Get("name")
+	firstGlobalUse, _ := records[0].Get("first_global_use")
+	response := AdoptionResponse{}
+	response.Vaccine, _ = name.(string)
+	response.FirstGlobalUse, _ = firstGlobalUse.(string)
+
+	var countries []AdoptionEntry
+	for _, record := range records {
+		country, _ := record.Get("country")
+		region, _ := record.Get("region")
+		firstUsed, _ := record.Get("first_used")
+		countryStr, ok := country.(string)
+		if !ok {
+			// A vaccine without any recorded use
+			continue
+		}
+		entry := AdoptionEntry{Country: countryStr}
+		entry.Region, _ = region.(string)
+		entry.FirstUse, _ = firstUsed.(string)
+		countries = append(countries, entry)
+	}
+
+	response.Countries, response.Timeline, response.Regions = adoption(countries, response.FirstGlobalUse)
+
+	utils.Respond(w, r, response)
+}
+
+// adoption fills in the lag of each country (ordered by first use) and
+// builds the overall and per-region timelines. Countries without a region
+// count in the overall timeline only; countries without a first use date
+// are left out, since they have neither a lag nor a place in the timelines.
+func adoption(countries []AdoptionEntry, firstGlobalUse string) ([]AdoptionEntry, []AdoptionPoint, []RegionAdoption) {
+	entries := make([]AdoptionEntry, 0, len(countries))
+	byRegion := map[string][]AdoptionEntry{}
+	for _, entry := range countries {
+		if entry.FirstUse == "" {
+			continue
+		}
+		entry.LagDays = daysBetween(firstGlobalUse, entry.FirstUse)
+		entries = append(entries, entry)
+		if entry.Region != "" {
+			byRegion[entry.Region] = append(byRegion[entry.Region], entry)
+		}
+	}
+
+	regions := make([]RegionAdoption, 0, len(byRegion))
+	for region, members := range byRegion {
+		regions = append(regions, RegionAdoption{
+			Region:        region,
+			Countries:     len(members),
+			FirstUse:      members[0].FirstUse,
+			LagDays:       members[0].LagDays,
+			MedianLagDays: medianLag(members),
+			Timeline:      timeline(members),
+		})
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		if regions[i].FirstUse != regions[j].FirstUse {
+			return regions[i].FirstUse < regions[j].FirstUse
+		}
+		return regions[i].Region < regions[j].Region
+	})
+
+	return entries, timeline(entries), regions
+}
+
+// timeline counts the countries adopting on each date, and the cumulative
+// total, from date-ordered entries.
+func timeline(entries []AdoptionEntry) []AdoptionPoint {
+	points := []AdoptionPoint{}
+	for i, entry := range entries {
+		if n := len(points); n > 0 && points[n-1].Date == entry.FirstUse {
+			points[n-1].NewCountries++
+		} else {
+			points = append(points, AdoptionPoint{Date: entry.FirstUse, NewCountries: 1})
+		}
+		points[len(points)-1].Countries = i + 1
+	}
+	return points
+}
+
+// medianLag returns the median adoption lag of lag-ordered entries.
+func medianLag(entries []AdoptionEntry) float64 {
+	n := len(entries)
+	if n%2 == 1 {
+		return float64(entries[n/2].LagDays)
+	}
+	return float64(entries[n/2-1].LagDays+entries[n/2].LagDays) / 2
+}
+
+// daysBetween returns the number of days from one YYYY-MM-DD date to another.
+func daysBetween(from, to string) int {
+	start, errStart := time.Parse("2006-01-02", from)
+	end, errEnd := time.Parse("2006-01-02", to)
+	if errStart != nil || errEnd != nil {
+		return 0
+	}
+	return int(end.Sub(start).Hours() / 24)
+}
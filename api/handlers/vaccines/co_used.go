@@ -0,0 +1,108 @@
+// Package vaccines handles COVID-19 vaccines information.
+//
+// Returns the vaccines most often used alongside the given vaccine (id or
+// slug), from the Country-[:USES]->Vaccine bipartite graph: the number of
+// countries using both and the Jaccard similarity of their sets of countries.
+
+package vaccines
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+type coUsedParams struct {
+	vaccinePath
+	Limit int    `query:"limit" default:"10" min:"1" max:"100"`
+	Sort  string `query:"sort" default:"count" enum:"count,jaccard"`
+}
+
+func HandleCoUsed(w http.ResponseWriter, r *http.Request) {
+	var p coUsedParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	property, vaccine, err := vaccineRef(p.VaccineID)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	limit, sortBy := p.Limit, p.Sort
+
+	// Every other vaccine sharing at least one country, with the number of
+	// shared countries and the number of countries using it at all.
+	query := fmt.Sprintf(`
+		MATCH (v:Vaccine {%s: $vaccine})
+		WITH v, COUNT { (v)<-[:USES]-(:Country) } AS countries
+		OPTIONAL MATCH (v)<-[:USES]-(c:Country)-[:USES]->(other:Vaccine)
+		WITH v, countries, other, count(c) AS shared
+		RETURN v.name AS name, countries, other.name AS vaccine, other.slug AS slug, shared,
+			COUNT { (other)<-[:USES]-(:Country) } AS other_countries
+	`, property)
+	params := map[string]interface{}{"vaccine": vaccine}
+
+	records, err := neo4j.Query(r.Context(), "vaccines.co_used."+property, query, params)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	if len(records) == 0 {
+		utils.RespondWithError(w, unknownVaccine())
+		return
+	}
+
+	name, _ := records[0].Get("name")
+	countries, _ := records[0].Get("countries")
+	response := CoUsageResponse{Entries: []CoUsageEntry{}}
+	response.Vaccine, _ = name.(string)
+	countriesInt, _ := countries.(int64)
+	response.Countries = int(countriesInt)
+
+	for _, record := range records {
+		other, _ := record.Get("vaccine")
+		otherStr, ok := other.(string)
+		if !ok {
+			// A vaccine not used alongside any other
+			continue
+		}
+		slug, _ := record.Get("slug")
+		shared, _ := record.Get("shared")
+		otherCountries, _ := record.Get("other_countries")
+		sharedInt, _ := shared.(int64)
+		otherInt, _ := otherCountries.(int64)
+
+		entry := CoUsageEntry{
+			Vaccine:         otherStr,
+			SharedCountries: int(sharedInt),
+			Countries:       int(otherInt),
+			Jaccard:         float64(sharedInt) / float64(countriesInt+otherInt-sharedInt),
+		}
+		entry.Slug, _ = slug.(string)
+		response.Entries = append(response.Entries, entry)
+	}
+
+	sort.Slice(response.Entries, func(i, j int) bool {
+		a, b := response.Entries[i], response.Entries[j]
+		if sortBy == "jaccard" && a.Jaccard != b.Jaccard {
+			return a.Jaccard > b.Jaccard
+		}
+		if a.SharedCountries != b.SharedCountries {
+			return a.SharedCountries > b.SharedCountries
+		}
+		if a.Jaccard != b.Jaccard {
+			return a.Jaccard > b.Jaccard
+		}
+		return a.Vaccine < b.Vaccine
+	})
+	if len(response.Entries) > limit {
+		response.Entries = response.Entries[:limit]
+	}
+
+	utils.Respond(w, r, response)
+}
@@ -0,0 +1,182 @@
+// Package vaccines handles COVID-19 vaccines information.
+//
+// Returns the cumulative doses administered per vaccine (manufacturer) and
+// country over time, either for one vaccine or for every vaccine used in a
+// country, with each vaccine's share of the country's doses.
+
+package vaccines
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+type vaccineDosesParams struct {
+	vaccinePath
+	Country param.Country `query:"country"`
+	param.Range
+}
+
+func HandleVaccineDoses(w http.ResponseWriter, r *http.Request) {
+	var p vaccineDosesParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	property, vaccine, err := vaccineRef(p.VaccineID)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	from, to := string(p.From), string(p.To)
+
+	// Records before `from` are fetched too, so that the first point's new
+	// doses are the difference to the previous record.
+	query := fmt.Sprintf(`
+		MATCH (v:Vaccine {%s: $vaccine})
+		OPTIONAL MATCH (c:Country)-[:ADMINISTERED]->(d:VaccineDoses)-[:OF_VACCINE]->(v)
+		WHERE d.date <= date($to) AND ($country = '' OR c.iso3 = $country)
+		RETURN v.name AS name, c.iso3 AS country, toString(d.date) AS date, d.totalDoses AS total
+		ORDER BY country, date
+	`, property)
+	params := map[string]interface{}{"vaccine": vaccine, "country": string(p.Country), "to": to}
+
+	records, err := neo4j.Query(r.Context(), "vaccines.doses."+property, query, params)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	if len(records) == 0 {
+		utils.RespondWithError(w, unknownVaccine())
+		return
+	}
+
+	name, _ := records[0].Get("name")
+	nameStr, _ := name.(string)
+
+	response := DosesResponse{
+		Context: nameStr,
+		From:    from,
+		To:      to,
+		Series:  doseSeries(records, "country", from, false),
+	}
+
+	utils.Respond(w, r, response)
+}
+
+type dosesInCountryParams struct {
+	Country param.Country `path:"country" required:"true"`
+	param.Range
+}
+
+func HandleDosesInCountry(w http.ResponseWriter, r *http.Request) {
+	var p dosesInCountryParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	country, from, to := string(p.Country), string(p.From), string(p.To)
+
+	query := `
+		MATCH (:Country {iso3: $country})-[:ADMINISTERED]->(d:VaccineDoses)
+		WHERE d.date <= date($to)
+		RETURN d.vaccine AS vaccine, toString(d.date) AS date, d.totalDoses AS total
+		ORDER BY date, vaccine
+	`
+	params := map[string]interface{}{"country": country, "to": to}
+
+	records, err := neo4j.Query(r.Context(), "vaccines.doses_in_country", query, params)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	if len(records) == 0 {
+		utils.RespondWithError(w, missingCountry(r.Context(), country, "No vaccine doses found for this country"))
+		return
+	}
+
+	response := DosesResponse{
+		Context: country,
+		From:    from,
+		To:      to,
+		Series:  doseSeries(records, "vaccine", from, true),
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// doseSeries groups date-ordered records (date, total and the key column)
+// into one series per key, computing the new doses of each point and
+// keeping only the points from `from` on.
+//
+// With withShare, every point also gets its series' share of the doses of
+// all series on that date, each series contributing its latest total
+// (series are not always reported on the same days). This requires the
+// records to be ordered by date first.
+func doseSeries(records []*neo4j.Record, key, from string, withShare bool) []DosesSeries {
+	series := []DosesSeries{}
+	index := map[string]int{}
+	latest := map[string]int64{}
+	var sum int64
+
+	// Points of the current date, whose share is known once the date ends
+	type position struct{ series, point int }
+	var pending []position
+	var pendingDate string
+	flush := func() {
+		for _, p := range pending {
+			point := &series[p.series].Points[p.point]
+			if sum > 0 {
+				point.Share = float64(point.Total) / float64(sum)
+			}
+		}
+		pending = pending[:0]
+	}
+
+	for _, record := range records {
+		keyRaw, _ := record.Get(key)
+		keyStr, ok := keyRaw.(string)
+		if !ok {
+			// A vaccine without any doses recorded
+			continue
+		}
+		dateRaw, _ := record.Get("date")
+		totalRaw, _ := record.Get("total")
+		date, _ := dateRaw.(string)
+		total, _ := totalRaw.(int64)
+
+		i, seen := index[keyStr]
+		if !seen {
+			i = len(series)
+			index[keyStr] = i
+			entry := DosesSeries{Points: []DosesPoint{}}
+			if key == "country" {
+				entry.Country = keyStr
+			} else {
+				entry.Vaccine = keyStr
+			}
+			series = append(series, entry)
+		}
+
+		if withShare && date != pendingDate {
+			flush()
+			pendingDate = date
+		}
+		sum += total - latest[keyStr]
+		point := DosesPoint{Date: date, Total: total, New: total - latest[keyStr]}
+		latest[keyStr] = total
+
+		if date >= from {
+			series[i].Points = append(series[i].Points, point)
+			if withShare {
+				pending = append(pending, position{i, len(series[i].Points) - 1})
+			}
+		}
+	}
+	flush()
+	return series
+}
@@ -1,42 +1,51 @@
 // Package vaccines handles COVID-19 vaccines information.
 //
-// It returns the date of global first use of each registered vaccine.
+// It returns the date of global first use of each registered vaccine,
+// sorted by date or name and paginated with a cursor.
 
 package vaccines
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
 	"github.com/biiafranca/viralgraph/api/utils"
 )
 
 func HandleFirstUse(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	session := neo4j.GetSession()
-	defer session.Close(ctx)
+	ctx := r.Context()
+
+	var p struct {
+		firstUseBounds
+		firstUsePage
+	}
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	page := p.Params(p.Sort)
 
 	query := `
 		MATCH (v:Vaccine)
 		WHERE v.first_global_use IS NOT NULL
+			AND ($after = '' OR toString(v.first_global_use) > $after)
+			AND ($before = '' OR toString(v.first_global_use) < $before)
 		RETURN v.name AS vaccine, v.first_global_use AS date
 		ORDER BY date
 	`
 
-	result, err := session.Run(ctx, query, nil)
+	params := map[string]interface{}{"after": string(p.After), "before": string(p.Before)}
+
+	records, err := neo4j.Query(ctx, "vaccines.first_use", query, params)
 	if err != nil {
-		log.Printf("Neo4j query failed: %v", err)
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to query database")
+		utils.RespondWithError(w, utils.DatabaseError())
 		return
 	}
 
-	var vaccineUsage []UsageEntry
-	for result.Next(ctx) {
-		record := result.Record()
+	vaccineUsage := []UsageEntry{}
+	for _, record := range records {
 		vaccine, _ := record.Get("vaccine")
 		date, _ := record.Get("date")
 		vaccineUsage = append(vaccineUsage, UsageEntry{
@@ -45,11 +54,12 @@ func HandleFirstUse(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	vaccineUsage, next := utils.Paginate(vaccineUsage, page, usageValue, usageKey)
 	response := UsageResponse{
-		Context: "worldwide",
-		Entries: vaccineUsage,
+		Context:    "worldwide",
+		Entries:    vaccineUsage,
+		Pagination: utils.NewPagination(w, r, page, next),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	utils.Respond(w, r, response)
 }
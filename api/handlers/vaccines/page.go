@@ -0,0 +1,72 @@
+// Package vaccines handles COVID-19 vaccines information.
+//
+// This file holds the pagination settings and filters shared by the vaccine
+// lists and the sort values of their entries.
+
+package vaccines
+
+import (
+	"fmt"
+
+	"github.com/biiafranca/viralgraph/api/param"
+)
+
+// firstUseBounds are the filters on the date of first use (exclusive).
+type firstUseBounds struct {
+	After  param.Date `query:"first-use-after"`
+	Before param.Date `query:"first-use-before"`
+}
+
+// usagePage declares the pagination of the usage lists, sorted by the name
+// of the entry (vaccine name or country code) or the date of first use.
+type usagePage struct {
+	param.Page
+	Sort param.Sort `query:"sort" default:"name" enum:"name,date"`
+}
+
+func (p usagePage) Validate() error { return p.Check(p.Sort) }
+
+// firstUsePage is the pagination of the first use list, a usage list
+// sorted by date by default.
+type firstUsePage struct {
+	param.Page
+	Sort param.Sort `query:"sort" default:"date" enum:"name,date"`
+}
+
+func (p firstUsePage) Validate() error { return p.Check(p.Sort) }
+
+// usageKey identifies a usage entry, which holds either a vaccine or a country.
+func usageKey(entry UsageEntry) string {
+	return entry.Vaccine + entry.Country
+}
+
+func usageValue(entry UsageEntry, sort string) string {
+	if sort == "date" {
+		return entry.FirstUse
+	}
+	return usageKey(entry)
+}
+
+// vaccinePage declares the pagination of the vaccine list, sorted by ID,
+// name or date of first global use.
+type vaccinePage struct {
+	param.Page
+	Sort param.Sort `query:"sort" default:"id" enum:"id,name,date"`
+}
+
+func (p vaccinePage) Validate() error { return p.Check(p.Sort) }
+
+// vaccineKey is the zero-padded ID, so that IDs sort as numbers.
+func vaccineKey(vaccine Vaccine) string {
+	return fmt.Sprintf("%010d", vaccine.ID)
+}
+
+func vaccineValue(vaccine Vaccine, sort string) string {
+	switch sort {
+	case "name":
+		return vaccine.Name
+	case "date":
+		return vaccine.FirstGlobalUse
+	}
+	return vaccineKey(vaccine)
+}
@@ -0,0 +1,59 @@
+// Package vaccines handles COVID-19 vaccines information.
+//
+// Resolves the vaccine referenced in a URL, either by its numeric id or by
+// its slug (e.g. pfizer-biontech). Slugs are derived from the vaccine name by
+// the ETL and stay the same across reloads, while ids follow row order.
+// Also builds the problems reported when a vaccine or country is not found.
+
+package vaccines
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// vaccinePath is the vaccine referenced in the path of per-vaccine endpoints.
+type vaccinePath struct {
+	VaccineID string `path:"vaccineID"`
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// vaccineRef returns the Vaccine property and value identifying the
+// referenced vaccine, to be matched as (v:Vaccine {<property>: $vaccine}).
+func vaccineRef(ref string) (string, interface{}, error) {
+	if ref == "" {
+		return "", nil, utils.MissingParam("vaccineID", "Vaccine parameter is required")
+	}
+	if id, err := strconv.Atoi(ref); err == nil {
+		return "id", id, nil
+	}
+	slug := strings.ToLower(ref)
+	if !slugPattern.MatchString(slug) {
+		return "", nil, utils.InvalidParam("vaccineID", "Invalid vaccine. Use its id or slug (e.g. pfizer-biontech)")
+	}
+	return "slug", slug, nil
+}
+
+// missingCountry explains an empty lookup for a country: the code is
+// unknown, or the country has no data (reported with the detail).
+func missingCountry(ctx context.Context, country, detail string) *utils.Problem {
+	known, err := neo4j.KnownCountries(ctx, []string{country})
+	if err != nil {
+		return utils.DatabaseError()
+	}
+	if !known[country] {
+		return utils.UnknownCountry(country)
+	}
+	return utils.NewProblem(utils.CodeNoData, detail)
+}
+
+// unknownVaccine is the problem reported when no vaccine matches the reference.
+func unknownVaccine() *utils.Problem {
+	return utils.NewProblem(utils.CodeUnknownVaccine, "No vaccine found for this ID or slug").WithParam("vaccineID")
+}
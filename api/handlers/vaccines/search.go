@@ -0,0 +1,128 @@
+// Package vaccines handles COVID-19 vaccines information.
+//
+// Searches vaccines by name, slug or alias. Matching is case-insensitive
+// and ignores punctuation and spaces ("pfizer biontech" finds
+// "Pfizer/BioNTech"), and tolerates typos through the edit distance
+// ("moderma" finds "Moderna"). There are only a few dozen vaccines, so the
+// whole list is scored in memory.
+
+package vaccines
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/biiafranca/viralgraph/api/param"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// minSimilarity is the lowest edit-distance similarity (1 for identical
+// strings, 0 for entirely different ones) accepted as a match.
+const minSimilarity = 0.6
+
+func HandleSearchVaccines(w http.ResponseWriter, r *http.Request) {
+	var p struct {
+		Q string `query:"q" required:"true" maxlen:"100"`
+	}
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	q := p.Q
+	if normalize(q) == "" {
+		utils.RespondWithError(w, utils.MissingParam("q", "Parameter q is required"))
+		return
+	}
+
+	vaccines, err := listVaccines(r.Context(), "vaccines.search", vaccineFilter{})
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+
+	response := SearchResponse{
+		Query:   q,
+		Results: search(vaccines, q),
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// search scores every vaccine against the query and returns the matching
+// ones, best first. A vaccine's score is the best score of its name, slug
+// and aliases: 1 for an exact match, 0.9 for a prefix, 0.8 for a substring
+// and the edit-distance similarity otherwise.
+func search(vaccines []Vaccine, q string) []SearchResult {
+	query := normalize(q)
+	results := []SearchResult{}
+	for _, vaccine := range vaccines {
+		best := SearchResult{Vaccine: vaccine}
+		candidates := append([]string{vaccine.Name, vaccine.Slug}, vaccine.Aliases...)
+		for _, candidate := range candidates {
+			if score := matchScore(query, normalize(candidate)); score > best.Score {
+				best.Score, best.MatchedOn = score, candidate
+			}
+		}
+		if best.Score >= minSimilarity {
+			results = append(results, best)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+func matchScore(query, candidate string) float64 {
+	switch {
+	case candidate == "":
+		return 0
+	case candidate == query:
+		return 1
+	case strings.HasPrefix(candidate, query):
+		return 0.9
+	case strings.Contains(candidate, query):
+		return 0.8
+	}
+	a, b := []rune(query), []rune(candidate)
+	longest := max(len(a), len(b))
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+// normalize lowercases s and keeps only its letters and digits.
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
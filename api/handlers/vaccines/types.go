@@ -4,11 +4,22 @@
 // Usage-related types (UsageEntry, UsageResponse) are used to represent generic
 // statistics of vaccine usage, either by country or by vaccine.
 //
-// Vaccine-related types (Vaccine, VaccinesResponse) focus on providing general
-// metadata about each vaccine.
+// Vaccine-related types (Vaccine, Approval, VaccinesResponse, SearchResult,
+// SearchResponse) focus on providing general metadata about each vaccine.
+//
+// Dose-related types (DosesPoint, DosesSeries, DosesResponse) represent the
+// cumulative doses administered per vaccine and country over time.
+//
+// Adoption-related types (AdoptionEntry, AdoptionPoint, RegionAdoption,
+// AdoptionResponse) describe how a vaccine spread across countries.
+//
+// Co-usage types (CoUsageEntry, CoUsageResponse) relate vaccines used in the
+// same countries.
 
 package vaccines
 
+import "github.com/biiafranca/viralgraph/api/utils"
+
 type UsageEntry struct {
 	Country  string `json:"country,omitempty"`
 	Vaccine  string `json:"vaccine,omitempty"`
@@ -16,16 +27,106 @@ type UsageEntry struct {
 }
 
 type UsageResponse struct {
-	Context string       `json:"context"`
-	Entries []UsageEntry `json:"entries"`
+	Context    string           `json:"context"`
+	Entries    []UsageEntry     `json:"entries" table:"rows"`
+	Pagination utils.Pagination `json:"pagination"`
 }
 
 type Vaccine struct {
-	ID             int    `json:"id"`
-	Name           string `json:"name"`
-	FirstGlobalUse string `json:"first_global_use"`
+	ID               int        `json:"id"`
+	Slug             string     `json:"slug"`
+	Name             string     `json:"name"`
+	Aliases          []string   `json:"aliases"`
+	FirstGlobalUse   string     `json:"first_global_use"`
+	Manufacturers    []string   `json:"manufacturers"`
+	Platform         string     `json:"platform,omitempty"`
+	Doses            int        `json:"doses,omitempty"`
+	DoseIntervalDays *int       `json:"dose_interval_days,omitempty"`
+	Approvals        []Approval `json:"approvals"`
+}
+
+type Approval struct {
+	Authority string `json:"authority"`
+	Type      string `json:"type"`
+	Date      string `json:"date"`
 }
 
 type VaccinesResponse struct {
-	Vaccines []Vaccine `json:"vaccines"`
+	Vaccines   []Vaccine        `json:"vaccines" table:"rows"`
+	Pagination utils.Pagination `json:"pagination"`
+}
+
+type SearchResult struct {
+	Vaccine
+	MatchedOn string  `json:"matched_on"`
+	Score     float64 `json:"score"`
+}
+
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results" table:"rows"`
+}
+
+type DosesPoint struct {
+	Date  string  `json:"date"`
+	Total int64   `json:"total"`
+	New   int64   `json:"new"`
+	Share float64 `json:"share,omitempty"`
+}
+
+type DosesSeries struct {
+	Country string       `json:"country,omitempty"`
+	Vaccine string       `json:"vaccine,omitempty"`
+	Points  []DosesPoint `json:"points" table:"rows"`
+}
+
+type DosesResponse struct {
+	Context string        `json:"context"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Series  []DosesSeries `json:"series" table:"rows"`
+}
+
+type AdoptionEntry struct {
+	Country  string `json:"country"`
+	Region   string `json:"region,omitempty"`
+	FirstUse string `json:"first_use"`
+	LagDays  int    `json:"lag_days"`
+}
+
+type AdoptionPoint struct {
+	Date         string `json:"date"`
+	NewCountries int    `json:"new_countries"`
+	Countries    int    `json:"countries"`
+}
+
+type RegionAdoption struct {
+	Region        string          `json:"region"`
+	Countries     int             `json:"countries"`
+	FirstUse      string          `json:"first_use"`
+	LagDays       int             `json:"lag_days"`
+	MedianLagDays float64         `json:"median_lag_days"`
+	Timeline      []AdoptionPoint `json:"timeline"`
+}
+
+type AdoptionResponse struct {
+	Vaccine        string           `json:"vaccine"`
+	FirstGlobalUse string           `json:"first_global_use"`
+	Timeline       []AdoptionPoint  `json:"timeline"`
+	Countries      []AdoptionEntry  `json:"countries" table:"rows"`
+	Regions        []RegionAdoption `json:"regions"`
+}
+
+type CoUsageEntry struct {
+	Vaccine         string  `json:"vaccine"`
+	Slug            string  `json:"slug"`
+	SharedCountries int     `json:"shared_countries"`
+	Countries       int     `json:"countries"`
+	Jaccard         float64 `json:"jaccard"`
+}
+
+type CoUsageResponse struct {
+	Vaccine   string         `json:"vaccine"`
+	Countries int            `json:"countries"`
+	Entries   []CoUsageEntry `json:"entries" table:"rows"`
 }
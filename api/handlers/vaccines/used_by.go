@@ -1,74 +1,87 @@
 // Package vaccines handles COVID-19 vaccines information.
 //
-// Returns the countries in which the given vaccineID was used and
-// the date of first use in each country.
+// Returns the countries in which the given vaccine (id or slug) was used
+// and the date of first use in each country. The countries can be filtered
+// by region and date of first use, and are paginated with a cursor.
 
 package vaccines
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
 	"github.com/biiafranca/viralgraph/api/utils"
+	"golang.org/x/sync/errgroup"
 )
 
+type usedByParams struct {
+	vaccinePath
+	Region string `query:"region"`
+	firstUseBounds
+	usagePage
+}
+
 func HandleUsedBy(w http.ResponseWriter, r *http.Request) {
-	vaccineIDStr := r.PathValue("vaccineID")
-	if vaccineIDStr == "" {
-		utils.RespondWithError(w, http.StatusBadRequest, "Vaccine ID parameter is required")
+	var p usedByParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
 		return
 	}
-	vaccineID, err := strconv.Atoi(vaccineIDStr)
+	property, vaccine, err := vaccineRef(p.VaccineID)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Vaccine ID must be an integer")
+		utils.RespondWithError(w, err)
 		return
 	}
+	page := p.Params(p.Sort)
+	region, after, before := p.Region, string(p.After), string(p.Before)
 
-	ctx := context.Background()
-	session := neo4j.GetSession()
-	defer session.Close(ctx)
+	ctx := r.Context()
 
-	queryName := `
-		MATCH (v:Vaccine {id: $id_vaccine})
+	queryName := fmt.Sprintf(`
+		MATCH (v:Vaccine {%s: $vaccine})
 		RETURN v.name As name
-	`
-	query := `
-		MATCH (c:Country)-[r:USES]->(v:Vaccine {id: $id_vaccine})
+	`, property)
+	query := fmt.Sprintf(`
+		MATCH (c:Country)-[r:USES]->(v:Vaccine {%s: $vaccine})
+		WHERE ($region = '' OR c.region = $region)
+			AND ($after = '' OR toString(r.first_used) > $after)
+			AND ($before = '' OR toString(r.first_used) < $before)
 		RETURN c.iso3 AS country, r.first_used AS date
 		ORDER BY country
-	`
-	params := map[string]interface{}{"id_vaccine": vaccineID}
+	`, property)
+	params := map[string]interface{}{"vaccine": vaccine, "region": region, "after": after, "before": before}
 
-	nameRes, errName := session.Run(ctx, queryName, params)
-	if errName != nil {
-		log.Printf("Neo4j query failed: %v", errName)
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to query database")
+	// The vaccine name and its usage are independent, so both queries run concurrently.
+	var nameRecords, records []*neo4j.Record
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		nameRecords, err = neo4j.Query(groupCtx, "vaccines.used_by.name."+property, queryName, params)
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		records, err = neo4j.Query(groupCtx, "vaccines.used_by."+property, query, params)
+		return err
+	})
+	if err := group.Wait(); err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
 		return
 	}
-	if !nameRes.Next(ctx) {
-		utils.RespondWithError(w, http.StatusNotFound, "No vaccine found for this ID")
+
+	if len(nameRecords) == 0 {
+		utils.RespondWithError(w, unknownVaccine())
 		return
 	}
 
-	record := nameRes.Record()
+	record := nameRecords[0]
 	contextName, _ := record.Get("name")
 	contextNameStr := contextName.(string)
 
-	result, err := session.Run(ctx, query, params)
-	if err != nil {
-		log.Printf("Neo4j query failed: %v", err)
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to query database")
-		return
-	}
-
-	var countryUsage []UsageEntry
-	for result.Next(ctx) {
-		record := result.Record()
+	countryUsage := []UsageEntry{}
+	for _, record := range records {
 		country, _ := record.Get("country")
 		date, _ := record.Get("date")
 		countryUsage = append(countryUsage, UsageEntry{
@@ -77,16 +90,18 @@ func HandleUsedBy(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	if len(countryUsage) == 0 {
-		utils.RespondWithError(w, http.StatusNotFound, "No usage data found for this vaccine")
+	filtered := region != "" || after != "" || before != ""
+	if len(countryUsage) == 0 && !filtered {
+		utils.RespondWithError(w, utils.NewProblem(utils.CodeNoData, "No usage data found for this vaccine"))
 		return
 	}
 
+	countryUsage, next := utils.Paginate(countryUsage, page, usageValue, usageKey)
 	response := UsageResponse{
-		Context: contextNameStr,
-		Entries: countryUsage,
+		Context:    contextNameStr,
+		Entries:    countryUsage,
+		Pagination: utils.NewPagination(w, r, page, next),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	utils.Respond(w, r, response)
 }
@@ -1,52 +1,55 @@
 // Package vaccines handles COVID-19 vaccines information.
 //
 // Returns the vaccines used in the given country (by the informed iso3 code)
-// and the date of first use in this country.
+// and the date of first use in this country. The vaccines can be filtered by
+// date of first use, and are paginated with a cursor.
 
 package vaccines
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"strings"
 
 	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
 	"github.com/biiafranca/viralgraph/api/utils"
 )
 
-func HandleUsedInCountry(w http.ResponseWriter, r *http.Request) {
+type usedInCountryParams struct {
+	Country param.Country `path:"country" required:"true"`
+	firstUseBounds
+	usagePage
+}
 
-	country := strings.ToUpper(r.PathValue("country"))
-	if country == "" {
-		utils.RespondWithError(w, http.StatusBadRequest, "Country parameter is required")
+func HandleUsedInCountry(w http.ResponseWriter, r *http.Request) {
+	var p usedInCountryParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
 		return
 	}
+	country, after, before := string(p.Country), string(p.After), string(p.Before)
+	page := p.Params(p.Sort)
 
-	ctx := context.Background()
-	session := neo4j.GetSession()
-	defer session.Close(ctx)
+	ctx := r.Context()
 
 	query := `
 		MATCH (c:Country {iso3: $country})-[r:USES]->(v:Vaccine)
+		WHERE ($after = '' OR toString(r.first_used) > $after)
+			AND ($before = '' OR toString(r.first_used) < $before)
 		RETURN v.name AS vaccine, r.first_used AS date
 		ORDER BY vaccine
 	`
 
-	params := map[string]interface{}{"country": country}
+	params := map[string]interface{}{"country": country, "after": after, "before": before}
 
-	result, err := session.Run(ctx, query, params)
+	records, err := neo4j.Query(ctx, "vaccines.used_in_country", query, params)
 	if err != nil {
-		log.Printf("Neo4j query failed: %v", err)
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to query database")
+		utils.RespondWithError(w, utils.DatabaseError())
 		return
 	}
 
-	var vaccineUsage []UsageEntry
-	for result.Next(ctx) {
-		record := result.Record()
+	vaccineUsage := []UsageEntry{}
+	for _, record := range records {
 		vaccine, _ := record.Get("vaccine")
 		date, _ := record.Get("date")
 		vaccineUsage = append(vaccineUsage, UsageEntry{
@@ -56,15 +59,19 @@ func HandleUsedInCountry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(vaccineUsage) == 0 {
-		utils.RespondWithError(w, http.StatusNotFound, "No vaccines found for this country")
-		return
+		// With filters, an empty list of a known country is a valid answer.
+		if problem := missingCountry(ctx, country, "No vaccines found for this country"); problem.Code != utils.CodeNoData || (after == "" && before == "") {
+			utils.RespondWithError(w, problem)
+			return
+		}
 	}
 
+	vaccineUsage, next := utils.Paginate(vaccineUsage, page, usageValue, usageKey)
 	response := UsageResponse{
-		Context: country,
-		Entries: vaccineUsage,
+		Context:    country,
+		Entries:    vaccineUsage,
+		Pagination: utils.NewPagination(w, r, page, next),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	utils.Respond(w, r, response)
 }
@@ -1,56 +1,211 @@
 // Package vaccines handles COVID-19 vaccines information.
 //
-// Returns the vaccines registered in the database and their general info, including the vaccine ID.
+// Returns the vaccines registered in the database and their general info:
+// ID, slug, known aliases, manufacturers, platform, dose schedule and
+// approvals. The list can be filtered by platform, manufacturer, approving
+// authority, number of doses and date of first global use, and is paginated
+// with a cursor.
 
 package vaccines
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
 
 	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/param"
 	"github.com/biiafranca/viralgraph/api/utils"
 )
 
+// vaccineFilter narrows down the vaccine list. Empty fields match any
+// vaccine; names are compared case-insensitively.
+type vaccineFilter struct {
+	Platform     string
+	Manufacturer string
+	ApprovedBy   string
+	Doses        int
+	// FirstUseAfter and FirstUseBefore bound the first global use
+	// (YYYY-MM-DD, exclusive).
+	FirstUseAfter  string
+	FirstUseBefore string
+}
+
+func (f vaccineFilter) params() map[string]interface{} {
+	return map[string]interface{}{
+		"platform":         strings.ToLower(f.Platform),
+		"manufacturer":     strings.ToLower(f.Manufacturer),
+		"approved_by":      strings.ToLower(f.ApprovedBy),
+		"doses":            f.Doses,
+		"first_use_after":  f.FirstUseAfter,
+		"first_use_before": f.FirstUseBefore,
+	}
+}
+
+type vaccinesParams struct {
+	Platform     string `query:"platform"`
+	Manufacturer string `query:"manufacturer"`
+	ApprovedBy   string `query:"approved-by"`
+	Doses        int    `query:"doses" min:"1"`
+	firstUseBounds
+	vaccinePage
+}
+
 func HandleVaccines(w http.ResponseWriter, r *http.Request) {
+	var p vaccinesParams
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	filter := vaccineFilter{
+		Platform:       p.Platform,
+		Manufacturer:   p.Manufacturer,
+		ApprovedBy:     p.ApprovedBy,
+		Doses:          p.Doses,
+		FirstUseAfter:  string(p.After),
+		FirstUseBefore: string(p.Before),
+	}
+	page := p.Params(p.Sort)
+
+	vaccines, err := listVaccines(r.Context(), "vaccines.list", filter)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+
+	vaccines, next := utils.Paginate(vaccines, page, vaccineValue, vaccineKey)
+	response := VaccinesResponse{
+		Vaccines:   vaccines,
+		Pagination: utils.NewPagination(w, r, page, next),
+	}
+
+	utils.Respond(w, r, response)
+}
+
+// HandleVaccine returns a single vaccine, referenced by ID or slug.
+func HandleVaccine(w http.ResponseWriter, r *http.Request) {
+	var p vaccinePath
+	if err := param.Parse(r, &p); err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
+	property, vaccine, err := vaccineRef(p.VaccineID)
+	if err != nil {
+		utils.RespondWithError(w, err)
+		return
+	}
 
-	ctx := context.Background()
-	session := neo4j.GetSession()
-	defer session.Close(ctx)
+	params := vaccineFilter{}.params()
+	params["vaccine"] = vaccine
+	match := fmt.Sprintf("MATCH (v:Vaccine {%s: $vaccine})", property)
 
-	query := `
-		MATCH (v:Vaccine)
-		RETURN v.id AS id, v.name AS vaccine, v.first_global_use AS date
+	vaccines, err := queryVaccines(r.Context(), "vaccines.get."+property, match, params)
+	if err != nil {
+		utils.RespondWithError(w, utils.DatabaseError())
+		return
+	}
+	if len(vaccines) == 0 {
+		utils.RespondWithError(w, unknownVaccine())
+		return
+	}
+
+	utils.Respond(w, r, vaccines[0])
+}
+
+// listVaccines returns the vaccines matching the filter, ordered by ID.
+func listVaccines(ctx context.Context, name string, filter vaccineFilter) ([]Vaccine, error) {
+	return queryVaccines(ctx, name, "MATCH (v:Vaccine)", filter.params())
+}
+
+// queryVaccines returns the vaccines found by the match clause (binding v)
+// that pass the filter parameters, with their metadata, ordered by ID.
+func queryVaccines(ctx context.Context, name, match string, params map[string]interface{}) ([]Vaccine, error) {
+	query := match + `
+		OPTIONAL MATCH (v)-[:USES_PLATFORM]->(p:Platform)
+		CALL {
+			WITH v
+			OPTIONAL MATCH (v)-[:MADE_BY]->(m:Manufacturer)
+			WITH m ORDER BY m.name
+			RETURN collect(m.name) AS manufacturers
+		}
+		CALL {
+			WITH v
+			OPTIONAL MATCH (v)-[:HAS_APPROVAL]->(a:Approval)
+			WITH a ORDER BY a.date
+			RETURN collect(a {.authority, .type, date: toString(a.date)}) AS approvals
+		}
+		WITH v, p, manufacturers, approvals
+		WHERE ($platform = '' OR toLower(p.name) = $platform)
+			AND ($manufacturer = '' OR any(m IN manufacturers WHERE toLower(m) = $manufacturer))
+			AND ($approved_by = '' OR any(a IN approvals WHERE toLower(a.authority) = $approved_by))
+			AND ($doses = 0 OR v.doses = $doses)
+			AND ($first_use_after = '' OR toString(v.first_global_use) > $first_use_after)
+			AND ($first_use_before = '' OR toString(v.first_global_use) < $first_use_before)
+		RETURN v.id AS id, v.slug AS slug, v.name AS vaccine, v.aliases AS aliases,
+			v.first_global_use AS date, p.name AS platform, manufacturers,
+			v.doses AS doses, v.dose_interval_days AS interval, approvals
 		ORDER BY id
 	`
 
-	result, err := session.Run(ctx, query, nil)
+	records, err := neo4j.Query(ctx, name, query, params)
 	if err != nil {
-		log.Printf("Neo4j query failed: %v", err)
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to query database")
-		return
+		return nil, err
 	}
 
-	var vaccines []Vaccine
-	for result.Next(ctx) {
-		record := result.Record()
+	vaccines := []Vaccine{}
+	for _, record := range records {
 		id, _ := record.Get("id")
+		slug, _ := record.Get("slug")
 		vaccine, _ := record.Get("vaccine")
+		aliases, _ := record.Get("aliases")
 		date, _ := record.Get("date")
-		vaccines = append(vaccines, Vaccine{
+		platform, _ := record.Get("platform")
+		manufacturers, _ := record.Get("manufacturers")
+		doses, _ := record.Get("doses")
+		interval, _ := record.Get("interval")
+		approvals, _ := record.Get("approvals")
+
+		entry := Vaccine{
 			ID:             int(id.(int64)),
 			Name:           vaccine.(string),
+			Aliases:        stringList(aliases),
 			FirstGlobalUse: fmt.Sprint(date),
-		})
-	}
+			Manufacturers:  stringList(manufacturers),
+			Approvals:      []Approval{},
+		}
+		entry.Slug, _ = slug.(string)
+		entry.Platform, _ = platform.(string)
+		if dosesInt, ok := doses.(int64); ok {
+			entry.Doses = int(dosesInt)
+		}
+		if intervalInt, ok := interval.(int64); ok {
+			days := int(intervalInt)
+			entry.DoseIntervalDays = &days
+		}
 
-	response := VaccinesResponse{
-		Vaccines: vaccines,
+		list, _ := approvals.([]interface{})
+		for _, item := range list {
+			approval, _ := item.(map[string]interface{})
+			authority, _ := approval["authority"].(string)
+			kind, _ := approval["type"].(string)
+			approvalDate, _ := approval["date"].(string)
+			entry.Approvals = append(entry.Approvals, Approval{Authority: authority, Type: kind, Date: approvalDate})
+		}
+
+		vaccines = append(vaccines, entry)
 	}
+	return vaccines, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// stringList converts a Neo4j list of strings.
+func stringList(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
 }
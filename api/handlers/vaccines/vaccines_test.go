@@ -1,10 +1,13 @@
 package vaccines
 
 import (
+	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/biiafranca/viralgraph/api/utils"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -76,6 +79,11 @@ func TestHandleUsedInCountry_Route_NonexistentCountry(t *testing.T) {
 	if rec.Code != http.StatusNotFound {
 		t.Errorf("expected 404 for nonexistent country, got %d", rec.Code)
 	}
+	var problem utils.Problem
+	json.NewDecoder(rec.Body).Decode(&problem)
+	if problem.Code != utils.CodeUnknownCountry {
+		t.Errorf("expected an unknown-country problem, got %+v", problem)
+	}
 }
 
 func TestHandleVaccines_Positive(t *testing.T) {
@@ -98,3 +106,386 @@ func TestHandleVaccines_Route(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
 	}
 }
+
+func TestHandleVaccines_PlatformFilter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleVaccines(rec, httptest.NewRequest(http.MethodGet, "/vaccines?platform=mrna", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response VaccinesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Vaccines) == 0 {
+		t.Fatal("expected mRNA vaccines")
+	}
+	for _, vaccine := range response.Vaccines {
+		if vaccine.Platform != "mRNA" {
+			t.Errorf("expected only mRNA vaccines, got %s (%s)", vaccine.Name, vaccine.Platform)
+		}
+	}
+}
+
+func TestHandleVaccines_InvalidDoses(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleVaccines(rec, httptest.NewRequest(http.MethodGet, "/vaccines?doses=zero", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleVaccine_Route(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}", HandleVaccine)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/pfizer-biontech", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var vaccine Vaccine
+	if err := json.NewDecoder(rec.Body).Decode(&vaccine); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if vaccine.Platform != "mRNA" || len(vaccine.Manufacturers) == 0 || len(vaccine.Approvals) == 0 {
+		t.Errorf("expected platform, manufacturers and approvals, got %+v", vaccine)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/99999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for nonexistent vaccine, got %d", rec.Code)
+	}
+}
+
+func TestHandleVaccine_InvalidVaccine(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}", HandleVaccine)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/pfizer_biontech", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleVaccineDoses_InvalidRange(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}/doses", HandleVaccineDoses)
+
+	req := httptest.NewRequest(http.MethodGet, "/vaccines/1/doses?from=2021-13-01", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for invalid date, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleDosesInCountry_Shares(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/used-in/{country}/doses", HandleDosesInCountry)
+
+	// Country present in the manufacturer data of the test database
+	req := httptest.NewRequest(http.MethodGet, "/vaccines/used-in/DEU/doses?from=2021-06-01&to=2021-06-30", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response DosesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	shares := map[string]float64{}
+	for _, series := range response.Series {
+		for _, point := range series.Points {
+			if point.Date < response.From || point.Date > response.To {
+				t.Errorf("%s: point %s outside the requested range", series.Vaccine, point.Date)
+			}
+			shares[point.Date] += point.Share
+		}
+	}
+	for date, share := range shares {
+		if share > 1+1e-9 || math.IsNaN(share) {
+			t.Errorf("expected shares on %s to add up to at most 1, got %f", date, share)
+		}
+	}
+}
+
+func TestHandleUsedBy_Route_Slug(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}/used-by", HandleUsedBy)
+
+	req := httptest.NewRequest(http.MethodGet, "/vaccines/pfizer-biontech/used-by", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestHandleUsedBy_Route_InvalidVaccine(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}/used-by", HandleUsedBy)
+
+	req := httptest.NewRequest(http.MethodGet, "/vaccines/Pfizer%20BioNTech/used-by", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestVaccineRef(t *testing.T) {
+	cases := []struct {
+		ref      string
+		property string
+		value    interface{}
+	}{
+		{"8", "id", 8},
+		{"pfizer-biontech", "slug", "pfizer-biontech"},
+		{"Sputnik-V", "slug", "sputnik-v"},
+	}
+	for _, c := range cases {
+		property, value, err := vaccineRef(c.ref)
+		if err != nil || property != c.property || value != c.value {
+			t.Errorf("vaccineRef(%q) = %q, %v, %v; want %q, %v", c.ref, property, value, err, c.property, c.value)
+		}
+	}
+	for _, ref := range []string{"", "pfizer/biontech", "-moderna", "a--b"} {
+		if _, _, err := vaccineRef(ref); err == nil {
+			t.Errorf("vaccineRef(%q): expected an error", ref)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	vaccines := []Vaccine{
+		{ID: 5, Slug: "moderna", Name: "Moderna", Aliases: []string{"Spikevax", "mRNA-1273"}},
+		{ID: 7, Slug: "oxford-astrazeneca", Name: "Oxford/AstraZeneca", Aliases: []string{"AstraZeneca", "Vaxzevria"}},
+		{ID: 8, Slug: "pfizer-biontech", Name: "Pfizer/BioNTech", Aliases: []string{"Comirnaty", "Pfizer"}},
+	}
+	cases := []struct {
+		q         string
+		first     string
+		matchedOn string
+	}{
+		{"pfizer biontech", "Pfizer/BioNTech", "Pfizer/BioNTech"},
+		{"COMIRNATY", "Pfizer/BioNTech", "Comirnaty"},
+		{"astra", "Oxford/AstraZeneca", "AstraZeneca"},
+		{"moderma", "Moderna", "Moderna"},
+		{"spikevac", "Moderna", "Spikevax"},
+	}
+	for _, c := range cases {
+		results := search(vaccines, c.q)
+		if len(results) == 0 {
+			t.Errorf("search(%q): expected results", c.q)
+			continue
+		}
+		if results[0].Name != c.first || results[0].MatchedOn != c.matchedOn {
+			t.Errorf("search(%q): got %s (on %s), want %s (on %s)", c.q, results[0].Name, results[0].MatchedOn, c.first, c.matchedOn)
+		}
+	}
+	if results := search(vaccines, "sinovac"); len(results) != 0 {
+		t.Errorf("search(sinovac): expected no results, got %v", results)
+	}
+}
+
+func TestHandleSearchVaccines_InvalidQuery(t *testing.T) {
+	for _, path := range []string{"/vaccines/search", "/vaccines/search?q=%20-%20"} {
+		rec := httptest.NewRecorder()
+		HandleSearchVaccines(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestHandleSearchVaccines_Positive(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleSearchVaccines(rec, httptest.NewRequest(http.MethodGet, "/vaccines/search?q=pfizer", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response SearchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Results) == 0 || response.Results[0].Slug != "pfizer-biontech" {
+		t.Errorf("expected pfizer-biontech first, got %+v", response.Results)
+	}
+}
+
+func TestAdoption(t *testing.T) {
+	countries := []AdoptionEntry{
+		{Country: "GBR", Region: "Europe", FirstUse: "2020-12-08"},
+		{Country: "USA", Region: "North America", FirstUse: "2020-12-14"},
+		{Country: "CAN", Region: "North America", FirstUse: "2020-12-14"},
+		{Country: "DEU", Region: "Europe", FirstUse: "2020-12-27"},
+		{Country: "FRA", Region: "Europe", FirstUse: "2020-12-27"},
+		{Country: "XKX", FirstUse: "2021-03-01"},
+		// Uses without a first use date sort last and are left out.
+		{Country: "PRK", Region: "Asia"},
+	}
+	entries, overall, regions := adoption(countries, "2020-12-08")
+
+	if len(entries) != 6 {
+		t.Fatalf("expected the countries without a first use to be left out, got %+v", entries)
+	}
+
+	if entries[0].LagDays != 0 || entries[1].LagDays != 6 || entries[5].LagDays != 83 {
+		t.Errorf("unexpected lags: %+v", entries)
+	}
+
+	want := []AdoptionPoint{
+		{Date: "2020-12-08", NewCountries: 1, Countries: 1},
+		{Date: "2020-12-14", NewCountries: 2, Countries: 3},
+		{Date: "2020-12-27", NewCountries: 2, Countries: 5},
+		{Date: "2021-03-01", NewCountries: 1, Countries: 6},
+	}
+	if len(overall) != len(want) {
+		t.Fatalf("expected %d timeline points, got %+v", len(want), overall)
+	}
+	for i := range want {
+		if overall[i] != want[i] {
+			t.Errorf("point %d: expected %+v, got %+v", i, want[i], overall[i])
+		}
+	}
+
+	if len(regions) != 2 || regions[0].Region != "Europe" || regions[1].Region != "North America" {
+		t.Fatalf("expected Europe then North America, got %+v", regions)
+	}
+	europe := regions[0]
+	if europe.Countries != 3 || europe.LagDays != 0 || europe.MedianLagDays != 19 || len(europe.Timeline) != 2 {
+		t.Errorf("unexpected Europe adoption: %+v", europe)
+	}
+	if northAmerica := regions[1]; northAmerica.LagDays != 6 || northAmerica.MedianLagDays != 6 {
+		t.Errorf("unexpected North America adoption: %+v", northAmerica)
+	}
+}
+
+func TestHandleAdoption_Route(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}/adoption", HandleAdoption)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/pfizer-biontech/adoption", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response AdoptionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if n := len(response.Timeline); n == 0 || response.Timeline[n-1].Countries != len(response.Countries) {
+		t.Errorf("expected the timeline to end with every adopting country, got %+v", response.Timeline)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/99999/adoption", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for nonexistent vaccine, got %d", rec.Code)
+	}
+}
+
+func TestHandleCoUsed_InvalidSort(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}/co-used", HandleCoUsed)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/moderna/co-used?sort=name", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestHandleCoUsed_Route(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines/{vaccineID}/co-used", HandleCoUsed)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/vaccines/pfizer-biontech/co-used?sort=jaccard", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response CoUsageResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Entries) == 0 {
+		t.Fatal("expected vaccines used alongside Pfizer/BioNTech")
+	}
+	for i, entry := range response.Entries {
+		if entry.Vaccine == response.Vaccine {
+			t.Error("expected the vaccine itself to be excluded")
+		}
+		if entry.SharedCountries > response.Countries || entry.SharedCountries > entry.Countries {
+			t.Errorf("shared countries exceed the countries using a vaccine: %+v", entry)
+		}
+		if i > 0 && entry.Jaccard > response.Entries[i-1].Jaccard {
+			t.Errorf("expected descending scores, got %v after %v", entry.Jaccard, response.Entries[i-1].Jaccard)
+		}
+	}
+}
+
+func TestHandleVaccineLists_InvalidPage(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/vaccines", HandleVaccines)
+	r.Get("/vaccines/first-use", HandleFirstUse)
+	r.Get("/vaccines/{vaccineID}/used-by", HandleUsedBy)
+	r.Get("/vaccines/used-in/{country}", HandleUsedInCountry)
+
+	for _, target := range []string{
+		"/vaccines?sort=platform",
+		"/vaccines?cursor=invalid",
+		"/vaccines?first-use-after=2021-02-30",
+		"/vaccines/first-use?limit=0",
+		"/vaccines/first-use?sort=country",
+		"/vaccines/pfizer-biontech/used-by?first-use-before=yesterday",
+		"/vaccines/pfizer-biontech/used-by?sort=vaccine",
+		"/vaccines/used-in/BRA?cursor=e30",
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, target, rec.Code)
+		}
+	}
+}
+
+func TestHandleVaccines_Pagination(t *testing.T) {
+	var names []string
+	target := "/vaccines?sort=name&limit=2"
+	for target != "" {
+		rec := httptest.NewRecorder()
+		HandleVaccines(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		var response VaccinesResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Vaccines) > 2 {
+			t.Fatalf("expected at most 2 vaccines per page, got %d", len(response.Vaccines))
+		}
+		for _, vaccine := range response.Vaccines {
+			names = append(names, vaccine.Name)
+		}
+		target = response.Pagination.Next
+	}
+
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("expected vaccines sorted by name, got %v", names)
+			break
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package logging configures structured JSON logging for the API.
+//
+// It provides request ID propagation through the request context,
+// an access log middleware and the slow-query threshold used by the
+// Neo4j layer.
+
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/metrics"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// RequestIDHeader is read from incoming requests and echoed in responses.
+const RequestIDHeader = "X-Request-Id"
+
+type ctxKey struct{}
+
+// slowQueryThreshold is the elapsed time above which a query is logged as slow.
+var slowQueryThreshold = 500 * time.Millisecond
+
+// Setup installs a JSON slog logger as the default logger.
+//
+// LOG_LEVEL (debug, info, warn, error) sets the minimum level, and
+// SLOW_QUERY_THRESHOLD (a Go duration, e.g. "250ms"; "0" disables it)
+// sets the slow-query log threshold.
+func Setup() {
+	level := slog.LevelInfo
+	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+
+	if value := os.Getenv("SLOW_QUERY_THRESHOLD"); value != "" {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			slog.Warn("Invalid SLOW_QUERY_THRESHOLD, keeping default",
+				"value", value, "default", slowQueryThreshold.String())
+		} else {
+			slowQueryThreshold = threshold
+		}
+	}
+}
+
+// SlowQueryThreshold returns the configured slow-query threshold.
+// A zero value means the slow-query log is disabled.
+func SlowQueryThreshold() time.Duration {
+	return slowQueryThreshold
+}
+
+// RequestID returns the request ID stored in ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the default logger annotated with the request ID
+// stored in ctx, if any.
+func FromContext(ctx context.Context) *slog.Logger {
+	if id := RequestID(ctx); id != "" {
+		return slog.Default().With("request_id", id)
+	}
+	return slog.Default()
+}
+
+// validRequestID matches the incoming request IDs that are reused. Others
+// (too long, or with characters that could forge log lines or headers) are
+// replaced by a generated one.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestIDMiddleware assigns a request ID to each request, reusing the
+// incoming X-Request-Id header when it is valid, and echoes it in the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
+
+// AccessLogMiddleware writes one structured log line per request, with
+// the request ID, route pattern, status and duration.
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := utils.NewStatusRecorder(w)
+
+		next.ServeHTTP(sw, r)
+
+		FromContext(r.Context()).Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", metrics.RoutePattern(r),
+			"status", sw.Status,
+			"bytes", sw.Bytes,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequestIDMiddleware_ReusesIncomingHeader(t *testing.T) {
+	var seen string
+	h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if seen != "abc123" {
+		t.Errorf("expected request ID abc123 in context, got %q", seen)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "abc123" {
+		t.Errorf("expected request ID echoed in response, got %q", got)
+	}
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RequestIDMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Header().Get(RequestIDHeader) == "" {
+		t.Error("expected a generated request ID")
+	}
+}
+
+func TestRequestIDMiddleware_ReplacesInvalidID(t *testing.T) {
+	for _, incoming := range []string{"abc 123", "id\ninjected", "<script>", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		rec := httptest.NewRecorder()
+		RequestIDMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rec, req)
+
+		if got := rec.Header().Get(RequestIDHeader); got == incoming || got == "" {
+			t.Errorf("%q: expected a generated request ID, got %q", incoming, got)
+		}
+	}
+}
+
+func TestAccessLogMiddleware_LogsRouteAndStatus(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(previous)
+
+	r := chi.NewRouter()
+	r.Use(RequestIDMiddleware)
+	r.Use(AccessLogMiddleware)
+	r.Get("/vaccines/used-in/{country}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/vaccines/used-in/XYZ", nil))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry["route"] != "/vaccines/used-in/{country}" {
+		t.Errorf("expected route pattern in access log, got %v", entry["route"])
+	}
+	if entry["status"] != float64(http.StatusNotFound) {
+		t.Errorf("expected status 404 in access log, got %v", entry["status"])
+	}
+	if entry["request_id"] == nil || entry["request_id"] == "" {
+		t.Error("expected request_id in access log")
+	}
+}
+
+func TestFromContext_WithoutRequestID(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("expected the default logger when no request ID is set")
+	}
+}
@@ -1,32 +1,115 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/biiafranca/viralgraph/api/cache"
+	"github.com/biiafranca/viralgraph/api/logging"
+	"github.com/biiafranca/viralgraph/api/metrics"
+	"github.com/biiafranca/viralgraph/api/neo4j"
 	"github.com/biiafranca/viralgraph/api/routes"
+	"github.com/biiafranca/viralgraph/api/tracing"
+	"github.com/biiafranca/viralgraph/api/utils"
 	"github.com/go-chi/chi/v5"
 )
 
 func main() {
+	logging.Setup()
+
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	r := chi.NewRouter()
+	r.NotFound(utils.NotFound)
+	r.MethodNotAllowed(utils.MethodNotAllowed)
+	r.Use(tracing.Middleware)
+	r.Use(logging.RequestIDMiddleware)
+	r.Use(logging.AccessLogMiddleware)
+	r.Use(metrics.Middleware)
 
-	routes.RegisterCovidStatsRoutes(r)
-	routes.RegisterVaccinationRoutes(r)
-	routes.RegisterUsedVaccinesRoutes(r)
+	var middlewares []func(http.Handler) http.Handler
+	if size := envInt("RESPONSE_CACHE_SIZE", 1024); size > 0 {
+		responseCache := cache.NewResponseCache(size, envDuration("DATASET_VERSION_TTL", 30*time.Second), datasetVersion)
+		middlewares = append(middlewares, responseCache.Middleware)
+	}
+	for _, version := range routes.Versions() {
+		routes.Mount(r, version, middlewares...)
+	}
+	routes.RegisterMetricsRoutes(r)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	fmt.Printf("Server listening on 0.0.0.0:%s\n", port)
-	fmt.Println("If you're running locally, access: http://localhost:" + port)
+	server := &http.Server{Addr: ":" + port, Handler: r}
 
-	err := http.ListenAndServe(":"+port, r)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error to start server: %v\n", err)
-		os.Exit(1)
+	slog.Info("Server listening", "addr", "0.0.0.0:"+port)
+	slog.Info("If you're running locally, access: http://localhost:" + port)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		slog.Error("Error to start server", "error", err)
+		exitCode = 1
+	case <-ctx.Done():
+		slog.Info("Shutting down server")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Error to shut down server", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Error to flush traces", "error", err)
+	}
+
+	os.Exit(exitCode)
+}
+
+// datasetVersion adapts the Dataset node lookup to the response cache.
+func datasetVersion(ctx context.Context) (cache.DatasetVersion, error) {
+	id, loadedAt, err := neo4j.DatasetVersion(ctx)
+	return cache.DatasetVersion{ID: id, LoadedAt: loadedAt}, err
+}
+
+// envInt reads an integer environment variable, falling back to def.
+func envInt(name string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return value
+	}
+	return def
+}
+
+// envDuration reads a duration environment variable (e.g. "30s"), falling back to def.
+func envDuration(name string, def time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil {
+		return value
 	}
+	return def
 }
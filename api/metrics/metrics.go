@@ -0,0 +1,133 @@
+// Package metrics exposes Prometheus instrumentation for the API.
+//
+// It collects per-route HTTP metrics, per-query Neo4j metrics,
+// driver pool utilisation and cache hit counters, and serves them on /metrics.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "viralgraph"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests processed, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "neo4j_query_duration_seconds",
+		Help:      "Neo4j query latency (execution and fetch), by query name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "neo4j_query_errors_total",
+		Help:      "Failed Neo4j queries, by query name.",
+	}, []string{"query"})
+
+	queryShared = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "neo4j_query_shared_total",
+		Help:      "Callers that shared a query execution with identical concurrent callers, by query name.",
+	}, []string{"query"})
+
+	poolInUse = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "neo4j_pool_in_use",
+		Help:      "Neo4j sessions currently executing a query.",
+	})
+
+	poolMax = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "neo4j_pool_max_size",
+		Help:      "Maximum size of the Neo4j connection pool.",
+	})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups, by cache name and result (hit or miss).",
+	}, []string{"cache", "result"})
+)
+
+// Handler returns the HTTP handler serving the Prometheus metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records request count and latency for every request.
+// Requests are labelled with the chi route pattern (e.g. /covid-stats/{date}),
+// not the raw path, so that label cardinality stays bounded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := utils.NewStatusRecorder(w)
+
+		next.ServeHTTP(sw, r)
+
+		route := RoutePattern(r)
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(sw.Status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RoutePattern returns the chi route pattern matched by the request,
+// or "unmatched" when no route was found.
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+// ObserveQuery records the latency and outcome of a named Neo4j query.
+func ObserveQuery(name string, elapsed time.Duration, err error) {
+	queryDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+	if err != nil {
+		queryErrors.WithLabelValues(name).Inc()
+	}
+}
+
+// QueryShared records a caller whose query execution was shared with
+// identical concurrent callers.
+func QueryShared(name string) {
+	queryShared.WithLabelValues(name).Inc()
+}
+
+// SetPoolMaxSize records the configured size of the Neo4j connection pool.
+func SetPoolMaxSize(size int) {
+	poolMax.Set(float64(size))
+}
+
+// SessionAcquired and SessionReleased track Neo4j sessions in use,
+// which together with the pool size gives the pool utilisation.
+func SessionAcquired() { poolInUse.Inc() }
+func SessionReleased() { poolInUse.Dec() }
+
+// CacheHit and CacheMiss record a lookup on the named cache.
+// The hit ratio is hits / (hits + misses).
+func CacheHit(cache string)  { cacheRequests.WithLabelValues(cache, "hit").Inc() }
+func CacheMiss(cache string) { cacheRequests.WithLabelValues(cache, "miss").Inc() }
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware_UsesRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/covid-stats/{country}/{date}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/covid-stats/BRA/2021-07-31", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/covid-stats/{country}/{date}", "404"))
+	if got != 1 {
+		t.Errorf("expected 1 request recorded for the route pattern, got %v", got)
+	}
+}
+
+func TestHandler_ExposesMetrics(t *testing.T) {
+	ObserveQuery("test.query", 0, nil)
+	CacheHit("test")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, name := range []string{"viralgraph_neo4j_query_duration_seconds", "viralgraph_cache_requests_total"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("expected %s in /metrics output", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package migrations manages the database schema (indexes and constraints).
+//
+// Migrations are applied in version order and each applied migration is
+// recorded as a SchemaMigration node, so applying them again only runs the
+// pending ones. They are the only source of the schema: the ETL creates no
+// indexes and refuses to load into a database without them (make etl-load
+// applies them first).
+
+package migrations
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+)
+
+// Migration is a numbered set of schema statements.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// All lists the migrations in version order. Versions start at 1 and
+// increase by one; applied migrations must never be edited.
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "initial indexes",
+		Statements: []string{
+			"CREATE INDEX IF NOT EXISTS FOR (c:Country) ON (c.iso3)",
+			"CREATE INDEX IF NOT EXISTS FOR (cc:CovidCase) ON (cc.id)",
+			"CREATE INDEX IF NOT EXISTS FOR (vs:VaccinationStats) ON (vs.id)",
+			"CREATE INDEX IF NOT EXISTS FOR (cc:CovidCase) ON (cc.country, cc.date)",
+			"CREATE INDEX IF NOT EXISTS FOR (vs:VaccinationStats) ON (vs.country, vs.date)",
+			"CREATE INDEX IF NOT EXISTS FOR (hs:HospitalStats) ON (hs.id)",
+			"CREATE INDEX IF NOT EXISTS FOR (hs:HospitalStats) ON (hs.country, hs.date)",
+			"CREATE INDEX IF NOT EXISTS FOR (ts:TestingStats) ON (ts.id)",
+			"CREATE INDEX IF NOT EXISTS FOR (ts:TestingStats) ON (ts.country, ts.date)",
+			"CREATE INDEX IF NOT EXISTS FOR (v:Vaccine) ON (v.name)",
+			"CREATE INDEX IF NOT EXISTS FOR (v:Vaccine) ON (v.slug)",
+			"CREATE INDEX IF NOT EXISTS FOR (m:Manufacturer) ON (m.name)",
+			"CREATE INDEX IF NOT EXISTS FOR (p:Platform) ON (p.name)",
+			"CREATE INDEX IF NOT EXISTS FOR (g:GlobalDailyStats) ON (g.date)",
+			"CREATE INDEX IF NOT EXISTS FOR (rs:RegionDailyStats) ON (rs.region, rs.date)",
+			"CREATE INDEX IF NOT EXISTS FOR (d:VaccineDoses) ON (d.id)",
+			"CREATE INDEX IF NOT EXISTS FOR (d:VaccineDoses) ON (d.country, d.vaccine, d.date)",
+		},
+	},
+	{
+		Version: 2,
+		Name:    "schema migration versions",
+		Statements: []string{
+			"CREATE CONSTRAINT schema_migration_version IF NOT EXISTS FOR (m:SchemaMigration) REQUIRE m.version IS UNIQUE",
+		},
+	},
+}
+
+// Latest returns the version of the last known migration.
+func Latest() int {
+	return All[len(All)-1].Version
+}
+
+// Current returns the version of the last migration applied to the
+// database, or 0 when none was.
+func Current(ctx context.Context) (int, error) {
+	records, err := neo4j.Query(ctx, "migrations.current", `
+		MATCH (m:SchemaMigration)
+		RETURN coalesce(max(m.version), 0) AS version
+	`, nil)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	version, _ := records[0].Get("version")
+	versionInt, _ := version.(int64)
+	return int(versionInt), nil
+}
+
+// Apply runs the migrations newer than the database's current version and
+// returns the ones it applied.
+func Apply(ctx context.Context) ([]Migration, error) {
+	current, err := Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending(current) {
+		// Schema and data changes cannot share a transaction, so each
+		// statement and the record of the migration run on their own.
+		for _, statement := range m.Statements {
+			if err := neo4j.Write(ctx, "migrations.apply", statement, nil); err != nil {
+				return applied, err
+			}
+		}
+		err := neo4j.Write(ctx, "migrations.record", `
+			CREATE (:SchemaMigration {version: $version, name: $name, applied_at: datetime()})
+		`, map[string]interface{}{"version": m.Version, "name": m.Name})
+		if err != nil {
+			return applied, err
+		}
+		slog.Info("Applied schema migration", "version", m.Version, "name", m.Name)
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// pending returns the migrations newer than the given version.
+func pending(current int) []Migration {
+	for i, m := range All {
+		if m.Version > current {
+			return All[i:]
+		}
+	}
+	return nil
+}
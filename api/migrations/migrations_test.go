@@ -0,0 +1,26 @@
+package migrations
+
+import "testing"
+
+func TestAll_Versions(t *testing.T) {
+	for i, m := range All {
+		if m.Version != i+1 {
+			t.Errorf("expected migration %q to have version %d, got %d", m.Name, i+1, m.Version)
+		}
+		if len(m.Statements) == 0 {
+			t.Errorf("migration %d has no statements", m.Version)
+		}
+	}
+}
+
+func TestPending(t *testing.T) {
+	if got := pending(0); len(got) != len(All) {
+		t.Errorf("expected every migration pending on a new database, got %d", len(got))
+	}
+	if got := pending(1); len(got) != len(All)-1 || got[0].Version != 2 {
+		t.Errorf("expected migrations after version 1, got %+v", got)
+	}
+	if got := pending(Latest()); len(got) != 0 {
+		t.Errorf("expected nothing pending at the latest version, got %+v", got)
+	}
+}
@@ -4,23 +4,47 @@ package neo4j
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
 	"time"
 
+	"github.com/biiafranca/viralgraph/api/logging"
+	"github.com/biiafranca/viralgraph/api/metrics"
+	"github.com/biiafranca/viralgraph/api/tracing"
 	"github.com/joho/godotenv"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
+	"golang.org/x/sync/singleflight"
 )
 
 var Driver neo4j.DriverWithContext
 
+// Record is a single row returned by a query.
+type Record = neo4j.Record
+
+const maxConnectionPoolSize = 10
+
+// TxConfigurer tunes the transaction a query runs in.
+type TxConfigurer = func(*neo4j.TransactionConfig)
+
+// WithTxTimeout makes the server abort the query after the given duration.
+func WithTxTimeout(timeout time.Duration) TxConfigurer {
+	return neo4j.WithTxTimeout(timeout)
+}
+
+// inflight coalesces identical queries running at the same time.
+var inflight singleflight.Group
+
 func init() {
 
 	if os.Getenv("DOCKER_ENV") != "true" {
 		envErr := godotenv.Load("../.env")
 		if envErr != nil {
-			log.Fatal("Error loading .env file")
+			slog.Error("Error loading .env file", "error", envErr)
+			os.Exit(1)
 		}
 	}
 
@@ -33,14 +57,16 @@ func init() {
 		neo4j.BasicAuth(user, password, ""),
 		func(config *config.Config) {
 			config.SocketConnectTimeout = 5 * time.Second
-			config.MaxConnectionPoolSize = 10 // limit threads
+			config.MaxConnectionPoolSize = maxConnectionPoolSize // limit threads
 		},
 	)
 	if driverErr != nil {
-		log.Fatalf("Failed to connect to Neo4j: %v", driverErr)
+		slog.Error("Failed to connect to Neo4j", "error", driverErr)
+		os.Exit(1)
 	}
 
 	Driver = driver
+	metrics.SetPoolMaxSize(maxConnectionPoolSize)
 }
 
 func GetSession() neo4j.SessionWithContext {
@@ -48,3 +74,238 @@ func GetSession() neo4j.SessionWithContext {
 		AccessMode: neo4j.AccessModeRead,
 	})
 }
+
+func getWriteSession() neo4j.SessionWithContext {
+	return Driver.NewSession(context.Background(), neo4j.SessionConfig{
+		AccessMode: neo4j.AccessModeWrite,
+	})
+}
+
+// Query runs a read query on a new session and returns all of its records.
+//
+// The name identifies the query in metrics and logs, so it should be
+// stable and low-cardinality (e.g. "covidstats.accumulated.country").
+// Failed queries and queries slower than logging.SlowQueryThreshold are
+// logged with the request ID carried by ctx, and each execution is traced
+// as a child span of the request.
+//
+// Concurrent calls with the same name, query and parameters share a single
+// execution and receive the same records, which callers must not modify.
+//
+// Optional configurers tune the transaction, e.g. WithTxTimeout to bound
+// the execution time of user-built queries.
+func Query(ctx context.Context, name, cypher string, params map[string]interface{}, configurers ...TxConfigurer) ([]*Record, error) {
+	// fmt prints maps with sorted keys, so equal params give equal keys.
+	key := fmt.Sprintf("%s\x00%s\x00%v", name, cypher, params)
+
+	leader := false
+	result, err, shared := inflight.Do(key, func() (interface{}, error) {
+		leader = true
+		// The execution outlives a caller that gives up, since others may be waiting on it.
+		return execute(context.WithoutCancel(ctx), name, cypher, params, configurers)
+	})
+	// Shared is also reported to the caller that ran the query; only the
+	// ones that waited on it count as shared.
+	if shared && !leader {
+		metrics.QueryShared(name)
+	}
+
+	records, _ := result.([]*Record)
+	return records, err
+}
+
+// execute runs a read query on a new session. Tests replace it to run
+// without a database.
+var execute = func(ctx context.Context, name, cypher string, params map[string]interface{}, configurers []TxConfigurer) ([]*Record, error) {
+	return run(ctx, GetSession(), name, cypher, params, configurers)
+}
+
+// Write runs a write query on a new write session, with the same metrics,
+// logs and tracing as Query. Writes are never coalesced.
+func Write(ctx context.Context, name, cypher string, params map[string]interface{}, configurers ...TxConfigurer) error {
+	_, err := run(ctx, getWriteSession(), name, cypher, params, configurers)
+	return err
+}
+
+// run executes a query on the session and closes it, recording metrics, logs and a trace span.
+func run(ctx context.Context, session neo4j.SessionWithContext, name, cypher string, params map[string]interface{}, configurers []TxConfigurer) ([]*Record, error) {
+	defer session.Close(ctx)
+
+	metrics.SessionAcquired()
+	defer metrics.SessionReleased()
+
+	ctx, span := tracing.StartQuery(ctx, name)
+	start := time.Now()
+	var records []*Record
+	var database string
+	result, err := session.Run(ctx, cypher, params, configurers...)
+	if err == nil {
+		records, err = result.Collect(ctx)
+	}
+	if err == nil {
+		if summary, summaryErr := result.Consume(ctx); summaryErr == nil {
+			database = summary.Database().Name()
+		}
+	}
+	observe(ctx, name, params, time.Since(start), len(records), err)
+	tracing.EndQuery(span, database, len(records), err)
+
+	return records, err
+}
+
+// Stream runs a read query on a new session and calls fn with each record
+// as it arrives, so that large results (e.g. a full dump) are never held in
+// memory. It stops at the first error returned by fn. Streamed queries are
+// not coalesced.
+func Stream(ctx context.Context, name, cypher string, params map[string]interface{}, fn func(*Record) error) error {
+	session := GetSession()
+	defer session.Close(ctx)
+
+	metrics.SessionAcquired()
+	defer metrics.SessionReleased()
+
+	ctx, span := tracing.StartQuery(ctx, name)
+	start := time.Now()
+	rows := 0
+	result, err := session.Run(ctx, cypher, params)
+	if err == nil {
+		for err == nil && result.Next(ctx) {
+			rows++
+			err = fn(result.Record())
+		}
+		if err == nil {
+			err = result.Err()
+		}
+	}
+	observe(ctx, name, params, time.Since(start), rows, err)
+	tracing.EndQuery(span, "", rows, err)
+
+	return err
+}
+
+// observe records the metrics and logs of a query execution.
+func observe(ctx context.Context, name string, params map[string]interface{}, elapsed time.Duration, rows int, err error) {
+	metrics.ObserveQuery(name, elapsed, err)
+
+	logger := logging.FromContext(ctx).With("query", name, "elapsed_ms", elapsed.Milliseconds())
+	switch threshold := logging.SlowQueryThreshold(); {
+	case err != nil:
+		logger.Error("Neo4j query failed", "params", loggedParams(params), "error", err)
+	case threshold > 0 && elapsed >= threshold:
+		logger.Warn("Slow Neo4j query", "params", loggedParams(params), "rows", rows)
+	default:
+		logger.Debug("Neo4j query", "rows", rows)
+	}
+}
+
+// maxLoggedItems bounds the lists logged with a query. Longer ones, such as
+// the rows of a restore batch or of a write-back, are logged as their length.
+const maxLoggedItems = 10
+
+// loggedParams returns the params as logged, with long lists summarised.
+func loggedParams(params map[string]interface{}) map[string]interface{} {
+	logged := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Slice && v.Len() > maxLoggedItems {
+			logged[name] = fmt.Sprintf("[%d items]", v.Len())
+		} else {
+			logged[name] = value
+		}
+	}
+	return logged
+}
+
+// IsTimeout reports whether err is the server aborting a query that
+// exceeded its transaction timeout.
+func IsTimeout(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	if !errors.As(err, &neo4jErr) {
+		return false
+	}
+	return neo4jErr.Code == "Neo.ClientError.Transaction.TransactionTimedOut" ||
+		neo4jErr.Code == "Neo.ClientError.Transaction.TransactionTimedOutClientConfiguration"
+}
+
+// DatasetVersion returns the version and load time of the dataset most
+// recently loaded by the ETL, as recorded on its Dataset node.
+// It returns an empty version when no Dataset node exists.
+func DatasetVersion(ctx context.Context) (string, time.Time, error) {
+	query := `
+		MATCH (d:Dataset)
+		RETURN d.version AS version, d.loaded_at AS loadedAt
+		ORDER BY d.loaded_at DESC
+		LIMIT 1
+	`
+
+	records, err := Query(ctx, "dataset.version", query, nil)
+	if err != nil || len(records) == 0 {
+		return "", time.Time{}, err
+	}
+
+	version, _ := records[0].Get("version")
+	loadedAt, _ := records[0].Get("loadedAt")
+	versionStr, _ := version.(string)
+	loadedAtTime, _ := loadedAt.(time.Time)
+
+	return versionStr, loadedAtTime, nil
+}
+
+// KnownCountries returns the ISO3 codes that belong to a Country node.
+// Handlers call it when a lookup comes back empty, to tell an unknown
+// country from a known one without data.
+func KnownCountries(ctx context.Context, codes []string) (map[string]bool, error) {
+	query := `
+		MATCH (c:Country)
+		WHERE c.iso3 IN $codes
+		RETURN c.iso3 AS iso3
+	`
+
+	records, err := Query(ctx, "countries.known", query, map[string]interface{}{"codes": codes})
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(records))
+	for _, record := range records {
+		iso3, _ := record.Get("iso3")
+		if code, ok := iso3.(string); ok {
+			known[code] = true
+		}
+	}
+	return known, nil
+}
+
+// KnownRegion reports whether any country belongs to the region.
+func KnownRegion(ctx context.Context, region string) (bool, error) {
+	query := `
+		MATCH (c:Country {region: $region})
+		RETURN count(c) > 0 AS known
+	`
+
+	records, err := Query(ctx, "regions.known", query, map[string]interface{}{"region": region})
+	if err != nil || len(records) == 0 {
+		return false, err
+	}
+	known, _ := records[0].Get("known")
+	return known == true, nil
+}
+
+// LatestDate returns the last date with data (YYYY-MM-DD), read from the
+// daily aggregates, or an empty string when none are loaded.
+func LatestDate(ctx context.Context) (string, error) {
+	query := `
+		MATCH (g:GlobalDailyStats)
+		WHERE g.date IS NOT NULL
+		RETURN toString(g.date) AS date
+		ORDER BY g.date DESC
+		LIMIT 1
+	`
+
+	records, err := Query(ctx, "dataset.latest_date", query, nil)
+	if err != nil || len(records) == 0 {
+		return "", err
+	}
+	date, _ := records[0].Get("date")
+	dateStr, _ := date.(string)
+	return dateStr, nil
+}
@@ -0,0 +1,96 @@
+package neo4j
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sharedCount reads the shared executions recorded for a query name.
+func sharedCount(t *testing.T, name string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "viralgraph_neo4j_query_shared_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "query" && label.GetValue() == name {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestQuery_CoalescesConcurrentCalls(t *testing.T) {
+	const callers = 5
+	saved := execute
+	t.Cleanup(func() { execute = saved })
+
+	var runs atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	want := []*Record{{Keys: []string{"cases"}, Values: []any{int64(1)}}}
+	execute = func(context.Context, string, string, map[string]interface{}, []TxConfigurer) ([]*Record, error) {
+		if runs.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return want, nil
+	}
+
+	before := sharedCount(t, "test.coalesced")
+	params := map[string]interface{}{"country": "BRA", "date": "2021-07-31"}
+	results := make([][]*Record, callers)
+	var wg sync.WaitGroup
+	call := func(i int) {
+		defer wg.Done()
+		results[i], _ = Query(context.Background(), "test.coalesced", "RETURN 1", params)
+	}
+
+	// The first call blocks in the database; the others arrive meanwhile.
+	wg.Add(callers)
+	go call(0)
+	<-started
+	for i := 1; i < callers; i++ {
+		go call(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("expected a single execution for %d identical calls, got %d", callers, got)
+	}
+	for i, records := range results {
+		if len(records) != 1 || records[0] != want[0] {
+			t.Errorf("caller %d: expected the shared records, got %v", i, records)
+		}
+	}
+	if got := sharedCount(t, "test.coalesced") - before; got != callers-1 {
+		t.Errorf("expected %d callers counted as shared, got %v", callers-1, got)
+	}
+}
+
+func TestLoggedParams_SummarisesLongLists(t *testing.T) {
+	rows := make([]map[string]interface{}, 1000)
+	logged := loggedParams(map[string]interface{}{"rows": rows, "countries": []string{"BRA", "FRA"}, "date": "2021-07-31"})
+
+	if logged["rows"] != "[1000 items]" {
+		t.Errorf("expected the rows to be logged as their count, got %v", logged["rows"])
+	}
+	if countries, ok := logged["countries"].([]string); !ok || len(countries) != 2 {
+		t.Errorf("expected short lists to be logged as they are, got %v", logged["countries"])
+	}
+	if logged["date"] != "2021-07-31" {
+		t.Errorf("expected scalar params to be logged as they are, got %v", logged["date"])
+	}
+}
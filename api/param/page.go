@@ -0,0 +1,82 @@
+// Package param parses and validates request parameters into typed structs.
+//
+// This file defines the pagination parameters of list endpoints: the page
+// size, the sort (a field, prefixed with "-" for descending order) and the
+// cursor of the page (see utils.Paginate).
+
+package param
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// Page declares the page size and cursor of list endpoints. Lists declare
+// their sort next to it, since their sort fields and defaults differ, and
+// check the cursor against it (see Check):
+//
+//	type usagePage struct {
+//		param.Page
+//		Sort param.Sort `query:"sort" default:"name" enum:"name,date"`
+//	}
+type Page struct {
+	Limit  int           `query:"limit" default:"100" min:"1" max:"1000"`
+	Cursor *utils.Cursor `query:"cursor"`
+}
+
+// Check rejects a cursor issued for another sort, since it means nothing
+// in another order.
+func (p Page) Check(sort Sort) error {
+	if p.Cursor != nil && p.Cursor.Sort != string(sort) {
+		return utils.InvalidParam("cursor", "Invalid cursor. Cursors are only valid for the sort they were returned with.")
+	}
+	return nil
+}
+
+// Params returns the page sorted by sort, for utils.Paginate.
+func (p Page) Params(sort Sort) utils.PageParams {
+	return utils.PageParams{Limit: p.Limit, Sort: sort.Field(), Desc: sort.Desc(), After: p.Cursor}
+}
+
+// Sort is a sort field, lower-cased and prefixed with "-" for descending
+// order. The enum tag lists the fields, without the prefix.
+type Sort string
+
+// Field returns the sort field, without the "-" prefix.
+func (s Sort) Field() string {
+	return strings.TrimPrefix(string(s), "-")
+}
+
+// Desc reports whether the sort is in descending order.
+func (s Sort) Desc() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// ParseSort lower-cases a sort and checks its field against a comma
+// separated list of fields. The param names the parameter in errors.
+func ParseSort(param, raw, enum string) (Sort, error) {
+	sort := Sort(strings.ToLower(raw))
+	if fields := strings.Split(enum, ","); !slices.Contains(fields, sort.Field()) {
+		return "", utils.InvalidParam(param, fmt.Sprintf("Invalid %s %q. Use one of: %s (prefix with - for descending order).", param, raw, strings.Join(fields, ", ")))
+	}
+	return sort, nil
+}
+
+// ParseCursor decodes a cursor returned in a previous page (see
+// utils.Cursor). The param names the parameter in errors.
+func ParseCursor(param, raw string) (*utils.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	var c utils.Cursor
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Sort == "" {
+		return nil, utils.InvalidParam(param, "Invalid cursor. Cursors are only valid for the sort they were returned with.")
+	}
+	return &c, nil
+}
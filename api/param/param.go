@@ -0,0 +1,244 @@
+// Package param parses and validates request parameters into typed structs.
+//
+// Handlers declare their path and query parameters as a struct, so that
+// dates, country codes, flags and limits are read the same way everywhere
+// and reported with the same errors. Each field names its parameter with a
+// path or query tag, and the other tags (default, required, enum, min, max,
+// maxlen and past) validate it:
+//
+//	type request struct {
+//		Country  param.Country `path:"country"`
+//		Date     param.Date    `path:"date" required:"true" past:"true"`
+//		Dose     string        `query:"dose" default:"first" enum:"first,full,booster,all-doses"`
+//		OnlyNews bool          `query:"only-news"`
+//		Limit    int           `query:"limit" default:"10" min:"1" max:"300"`
+//	}
+//
+// Query parameters not declared by the struct are rejected, apart from
+// format (see utils.Negotiate), so that typos such as onlyNews are reported
+// instead of silently ignored.
+
+package param
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+// global lists the query parameters accepted by every endpoint.
+var global = []string{"format"}
+
+// Validator is implemented by structs with rules spanning several
+// parameters (e.g. from must not be after to), checked after parsing.
+type Validator interface {
+	Validate() error
+}
+
+// field is a tagged struct field.
+type field struct {
+	index []int
+	name  string
+	path  bool
+	tag   reflect.StructTag
+}
+
+// fieldsOf lists the tagged fields of a struct type, with the fields of
+// embedded structs promoted.
+func fieldsOf(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for _, embedded := range fieldsOf(f.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if name, ok := f.Tag.Lookup("path"); ok {
+			fields = append(fields, field{index: []int{i}, name: name, path: true, tag: f.Tag})
+		} else if name, ok := f.Tag.Lookup("query"); ok {
+			fields = append(fields, field{index: []int{i}, name: name, tag: f.Tag})
+		}
+	}
+	return fields
+}
+
+// Parse fills the struct pointed to by dst from the request. It returns a
+// *utils.Problem naming the offending parameter when one is unknown,
+// missing or invalid.
+func Parse(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst).Elem()
+	fields := fieldsOf(v.Type())
+
+	var known []string
+	for _, f := range fields {
+		if !f.path {
+			known = append(known, f.name)
+		}
+	}
+	for name := range r.URL.Query() {
+		if !slices.Contains(known, name) && !slices.Contains(global, name) {
+			detail := fmt.Sprintf("Unknown parameter %q.", name)
+			if len(known) > 0 {
+				slices.Sort(known)
+				detail += " Use one of: " + strings.Join(known, ", ")
+			}
+			return utils.NewProblem(utils.CodeUnknownParameter, detail).WithParam(name)
+		}
+	}
+
+	for _, f := range fields {
+		var raw string
+		if f.path {
+			raw = r.PathValue(f.name)
+		} else {
+			raw = r.URL.Query().Get(f.name)
+		}
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			raw = f.tag.Get("default")
+		}
+		if raw == "" {
+			if f.tag.Get("required") == "true" {
+				return utils.MissingParam(f.name, fmt.Sprintf("Parameter %s is required", f.name))
+			}
+			continue
+		}
+		if err := set(r.Context(), v.FieldByIndex(f.index), f, raw); err != nil {
+			return err
+		}
+	}
+
+	if validator, ok := dst.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+var (
+	dateType    = reflect.TypeOf(Date(""))
+	countryType = reflect.TypeOf(Country(""))
+	sortType    = reflect.TypeOf(Sort(""))
+	cursorType  = reflect.TypeOf(&utils.Cursor{})
+)
+
+// set converts the raw value to the type of the field and checks it
+// against the field's tags.
+func set(ctx context.Context, v reflect.Value, f field, raw string) error {
+	switch v.Type() {
+	case dateType:
+		date, err := ParseDate(ctx, f.name, raw)
+		if err == nil && f.tag.Get("past") == "true" {
+			err = NotFuture(f.name, date)
+		}
+		if err != nil {
+			return err
+		}
+		v.SetString(string(date))
+		return nil
+	case countryType:
+		country, err := ParseCountry(f.name, raw)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(country))
+		return nil
+	case sortType:
+		sort, err := ParseSort(f.name, raw, f.tag.Get("enum"))
+		if err != nil {
+			return err
+		}
+		v.SetString(string(sort))
+		return nil
+	case cursorType:
+		cursor, err := ParseCursor(f.name, raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(cursor))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if enum := f.tag.Get("enum"); enum != "" {
+			value, err := ParseEnum(f.name, raw, enum)
+			if err != nil {
+				return err
+			}
+			raw = value
+		}
+		if max := f.tag.Get("maxlen"); max != "" {
+			if n, _ := strconv.Atoi(max); len(raw) > n {
+				return utils.InvalidParam(f.name, fmt.Sprintf("Parameter %s is too long (at most %d characters)", f.name, n))
+			}
+		}
+		v.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(strings.ToLower(raw))
+		if err != nil {
+			return utils.InvalidParam(f.name, fmt.Sprintf("Invalid %s. Use true or false.", f.name))
+		}
+		v.SetBool(value)
+	case reflect.Int:
+		value, err := strconv.Atoi(raw)
+		if err != nil || !inRange(f.tag, float64(value)) {
+			return utils.InvalidParam(f.name, fmt.Sprintf("Invalid %s. Use an integer%s.", f.name, describeRange(f.tag)))
+		}
+		v.SetInt(int64(value))
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !inRange(f.tag, value) {
+			return utils.InvalidParam(f.name, fmt.Sprintf("Invalid %s. Use a number%s.", f.name, describeRange(f.tag)))
+		}
+		v.SetFloat(value)
+	case reflect.Slice:
+		// Comma separated lists of strings, checked item by item.
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.ToLower(strings.TrimSpace(item))
+			if enum := f.tag.Get("enum"); enum != "" && !slices.Contains(strings.Split(enum, ","), item) {
+				return utils.InvalidParam(f.name, fmt.Sprintf("Unknown %s %q. Use a comma separated list of: %s.", f.name, item, strings.ReplaceAll(enum, ",", ", ")))
+			}
+			if !slices.Contains(items, item) {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		panic("param: unsupported field type " + v.Type().String())
+	}
+	return nil
+}
+
+// inRange checks a number against the min and max tags.
+func inRange(tag reflect.StructTag, value float64) bool {
+	if min, err := strconv.ParseFloat(tag.Get("min"), 64); err == nil && value < min {
+		return false
+	}
+	if max, err := strconv.ParseFloat(tag.Get("max"), 64); err == nil && value > max {
+		return false
+	}
+	return true
+}
+
+func describeRange(tag reflect.StructTag) string {
+	min, max := tag.Get("min"), tag.Get("max")
+	switch {
+	case min != "" && max != "":
+		return fmt.Sprintf(" between %s and %s", min, max)
+	case min != "":
+		return " of at least " + min
+	case max != "":
+		return " of at most " + max
+	}
+	return ""
+}
@@ -0,0 +1,190 @@
+package param
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+type request struct {
+	Country  Country  `path:"country"`
+	Date     Date     `path:"date" required:"true" past:"true"`
+	Dose     string   `query:"dose" default:"first" enum:"first,full"`
+	OnlyNews bool     `query:"only-news"`
+	Limit    int      `query:"limit" default:"10" min:"1" max:"300"`
+	Include  []string `query:"include" enum:"countries,vaccines"`
+	Range
+}
+
+// stub fixes today and the latest date with data for the test.
+func stub(t *testing.T) {
+	savedNow, savedLatest := now, latest
+	t.Cleanup(func() { now, latest = savedNow, savedLatest })
+
+	now = func() time.Time { return time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC) }
+	latest = func(context.Context) (string, error) { return "2024-03-10", nil }
+}
+
+func newRequest(target, country, date string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.SetPathValue("country", country)
+	r.SetPathValue("date", date)
+	return r
+}
+
+func TestParse(t *testing.T) {
+	stub(t)
+
+	var p request
+	r := newRequest("/?dose=FULL&only-news=true&limit=5&include=vaccines,countries,vaccines&from=latest-1w&format=csv", "bra", "latest")
+	if err := Parse(r, &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := request{
+		Country:  "BRA",
+		Date:     "2024-03-10",
+		Dose:     "full",
+		OnlyNews: true,
+		Limit:    5,
+		Include:  []string{"vaccines", "countries"},
+		Range:    Range{From: "2024-03-03", To: "2024-03-15"},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+}
+
+func TestParse_Defaults(t *testing.T) {
+	stub(t)
+
+	var p request
+	if err := Parse(newRequest("/", "", "2021-07-31"), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Country != "" || p.Dose != "first" || p.OnlyNews || p.Limit != 10 || p.Include != nil {
+		t.Errorf("unexpected defaults: %+v", p)
+	}
+	if p.From != "2020-01-01" || p.To != "2024-03-15" {
+		t.Errorf("expected the range 2020-01-01 to 2024-03-15, got %s to %s", p.From, p.To)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	stub(t)
+
+	for _, tc := range []struct {
+		target, country, date string
+		code, param           string
+	}{
+		{"/?onlyNews=true", "", "2021-07-31", utils.CodeUnknownParameter, "onlyNews"},
+		{"/", "", "", utils.CodeMissingParameter, "date"},
+		{"/", "", "31/07/2021", utils.CodeInvalidParameter, "date"},
+		{"/", "", "today+1d", utils.CodeFutureDate, "date"},
+		{"/", "BRAZIL", "2021-07-31", utils.CodeInvalidParameter, "country"},
+		{"/?dose=second", "", "2021-07-31", utils.CodeInvalidParameter, "dose"},
+		{"/?only-news=maybe", "", "2021-07-31", utils.CodeInvalidParameter, "only-news"},
+		{"/?limit=0", "", "2021-07-31", utils.CodeInvalidParameter, "limit"},
+		{"/?limit=301", "", "2021-07-31", utils.CodeInvalidParameter, "limit"},
+		{"/?limit=ten", "", "2021-07-31", utils.CodeInvalidParameter, "limit"},
+		{"/?include=uses", "", "2021-07-31", utils.CodeInvalidParameter, "include"},
+		{"/?from=2021-02-01&to=2021-01-01", "", "2021-07-31", utils.CodeInvalidParameter, "from"},
+	} {
+		var p request
+		err := Parse(newRequest(tc.target, tc.country, tc.date), &p)
+		problem := utils.AsProblem(err)
+		if err == nil || problem.Code != tc.code || problem.Param != tc.param {
+			t.Errorf("%s (%q, %q): expected a %s problem on %s, got %v", tc.target, tc.country, tc.date, tc.code, tc.param, problem)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	stub(t)
+
+	for raw, want := range map[string]Date{
+		"2021-07-31": "2021-07-31",
+		"today":      "2024-03-15",
+		"TODAY":      "2024-03-15",
+		"latest":     "2024-03-10",
+		"latest-7d":  "2024-03-03",
+		"today-1m":   "2024-02-15",
+		"-2w":        "2024-03-01",
+		"latest+1y":  "2025-03-10",
+	} {
+		got, err := ParseDate(context.Background(), "date", raw)
+		if err != nil || got != want {
+			t.Errorf("%s: expected %s, got %s (%v)", raw, want, got, err)
+		}
+	}
+
+	for _, raw := range []string{"", "yesterday", "latest-7", "7d", "2021-02-30"} {
+		if _, err := ParseDate(context.Background(), "date", raw); err == nil {
+			t.Errorf("%q: expected an error", raw)
+		}
+	}
+}
+
+func TestParseDate_NoData(t *testing.T) {
+	stub(t)
+	latest = func(context.Context) (string, error) { return "", nil }
+
+	_, err := ParseDate(context.Background(), "date", "latest")
+	if problem := utils.AsProblem(err); problem.Code != utils.CodeNoData {
+		t.Errorf("expected a no-data problem, got %v", problem)
+	}
+}
+
+type pageRequest struct {
+	Page
+	Sort Sort `query:"sort" default:"name" enum:"name,date"`
+}
+
+func (p pageRequest) Validate() error { return p.Check(p.Sort) }
+
+func TestParse_Page(t *testing.T) {
+	var p pageRequest
+	if err := Parse(httptest.NewRequest(http.MethodGet, "/", nil), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Limit != 100 || p.Sort != "name" || p.Cursor != nil {
+		t.Errorf("unexpected defaults %+v", p)
+	}
+
+	// eyJzIjoiLWRhdGUiLCJ2IjoiMjAyMS0wMS0wMiIsImsiOiJiIn0 is {"s":"-date","v":"2021-01-02","k":"b"}.
+	target := "/?limit=2&sort=-DATE&cursor=eyJzIjoiLWRhdGUiLCJ2IjoiMjAyMS0wMS0wMiIsImsiOiJiIn0"
+	p = pageRequest{}
+	if err := Parse(httptest.NewRequest(http.MethodGet, target, nil), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page := p.Params(p.Sort)
+	if page.Limit != 2 || page.Sort != "date" || !page.Desc {
+		t.Errorf("unexpected page %+v", page)
+	}
+	if want := (utils.Cursor{Sort: "-date", Value: "2021-01-02", Key: "b"}); page.After == nil || *page.After != want {
+		t.Errorf("expected the cursor %+v, got %+v", want, page.After)
+	}
+}
+
+func TestParse_PageInvalid(t *testing.T) {
+	for _, tc := range []struct{ query, param string }{
+		{"sort=country", "sort"},
+		{"sort=-", "sort"},
+		{"limit=0", "limit"},
+		{"limit=5000", "limit"},
+		{"cursor=%21%21", "cursor"},
+		{"cursor=e30", "cursor"},
+		// A cursor of the -date sort, sent with the default sort.
+		{"cursor=eyJzIjoiLWRhdGUiLCJ2IjoiMjAyMS0wMS0wMiIsImsiOiJiIn0", "cursor"},
+	} {
+		var p pageRequest
+		err := Parse(httptest.NewRequest(http.MethodGet, "/?"+tc.query, nil), &p)
+		if problem := utils.AsProblem(err); err == nil || problem.Param != tc.param {
+			t.Errorf("%s: expected a problem on %s, got %v", tc.query, tc.param, problem)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package param parses and validates request parameters into typed structs.
+//
+// This file defines the parameter types shared across endpoints: dates,
+// which may be relative to today or to the latest date with data, and
+// country codes.
+
+package param
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biiafranca/viralgraph/api/neo4j"
+	"github.com/biiafranca/viralgraph/api/utils"
+)
+
+const layout = "2006-01-02"
+
+// Date is a date parameter, resolved to YYYY-MM-DD. Besides dates, it
+// accepts today, latest (the last date with data) and offsets in days,
+// weeks, months or years from either, e.g. latest-7d, today-1m or -2w
+// (from today).
+type Date string
+
+// Country is an ISO3 country code, upper-cased.
+type Country string
+
+var (
+	relativeDate = regexp.MustCompile(`^(today|latest)?(?:([+-]\d+)([dwmy]))?$`)
+	countryCode  = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// latest returns the last date with data. It is a variable so that tests
+// can resolve latest without a database.
+var latest = func(ctx context.Context) (string, error) {
+	return neo4j.LatestDate(ctx)
+}
+
+// now is the current time, a variable for tests.
+var now = time.Now
+
+// ParseDate resolves a date parameter (see Date). The param names the
+// parameter in errors.
+func ParseDate(ctx context.Context, param, raw string) (Date, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if date, err := time.Parse(layout, raw); err == nil {
+		return Date(date.Format(layout)), nil
+	}
+
+	match := relativeDate.FindStringSubmatch(raw)
+	if match == nil || raw == "" {
+		return "", utils.InvalidParam(param, fmt.Sprintf("Invalid %s. Use YYYY-MM-DD, today, latest or an offset such as latest-7d.", param))
+	}
+
+	base := now()
+	if match[1] == "latest" {
+		date, err := latest(ctx)
+		if err != nil {
+			return "", utils.DatabaseError()
+		}
+		if date == "" {
+			return "", utils.NewProblem(utils.CodeNoData, "No data has been loaded yet").WithParam(param)
+		}
+		base, _ = time.Parse(layout, date)
+	}
+
+	if match[2] != "" {
+		n, _ := strconv.Atoi(match[2])
+		switch match[3] {
+		case "d":
+			base = base.AddDate(0, 0, n)
+		case "w":
+			base = base.AddDate(0, 0, 7*n)
+		case "m":
+			base = base.AddDate(0, n, 0)
+		case "y":
+			base = base.AddDate(n, 0, 0)
+		}
+	}
+	return Date(base.Format(layout)), nil
+}
+
+// NotFuture rejects dates after today, for which there is no data.
+func NotFuture(param string, date Date) error {
+	if string(date) > now().Format(layout) {
+		return utils.NewProblem(utils.CodeFutureDate, "No data available for future dates").WithParam(param)
+	}
+	return nil
+}
+
+// ParseCountry upper-cases and checks an ISO3 country code. The param
+// names the parameter in errors.
+func ParseCountry(param, raw string) (Country, error) {
+	code := strings.ToUpper(strings.TrimSpace(raw))
+	if !countryCode.MatchString(code) {
+		return "", utils.InvalidParam(param, fmt.Sprintf("Invalid %s %q. Use an ISO3 code (e.g. BRA).", param, raw))
+	}
+	return Country(code), nil
+}
+
+// ParseEnum lower-cases a value and checks it against a comma separated
+// list of allowed values, as the enum tag does. The param names the
+// parameter in errors.
+func ParseEnum(param, raw, enum string) (string, error) {
+	value := strings.ToLower(raw)
+	if !slices.Contains(strings.Split(enum, ","), value) {
+		return "", utils.InvalidParam(param, fmt.Sprintf("Invalid %s. Use one of: %s.", param, strings.ReplaceAll(enum, ",", ", ")))
+	}
+	return value, nil
+}
+
+// Range declares the from and to parameters of series, by default the
+// whole pandemic up to today.
+type Range struct {
+	From Date `query:"from" default:"2020-01-01"`
+	To   Date `query:"to" default:"today"`
+}
+
+func (r Range) Validate() error {
+	if r.From > r.To {
+		return utils.InvalidParam("from", "from must not be after to")
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package registry declares the statistics served by the API.
+//
+// Each metric is declared once, with where it is stored in the graph and how
+// its values evolve over time. The statistics handlers, their routes and the
+// generic query compiler are built from these declarations, so adding a
+// metric only takes a new entry below (and the ETL loading its property).
+
+package registry
+
+import "sort"
+
+// Kind describes how a metric's stored values relate to each other over time.
+type Kind string
+
+const (
+	// Cumulative metrics store running totals (e.g. total cases): the
+	// accumulated value on a date is the latest one on or before it, and the
+	// new value is the difference to the previous record.
+	Cumulative Kind = "cumulative"
+
+	// Gauge metrics store readings of a level or rate (e.g. patients in
+	// hospital): the accumulated value is the latest reading on or before
+	// the date and the new value is the reading of that exact date.
+	Gauge Kind = "gauge"
+)
+
+// Metric is a statistic stored on per-country time series nodes and on the
+// precomputed GlobalDailyStats and RegionDailyStats aggregates.
+type Metric struct {
+	// Name identifies the metric in URLs, query specs and result columns.
+	Name        string
+	Description string
+	Kind        Kind
+
+	// Label, Relationship and Latest locate the per-country records:
+	// (:Country)-[:Relationship]->(:Label), with (:Country)-[:Latest]->(:Label)
+	// pointing at the most recent one.
+	Label        string
+	Relationship string
+	Latest       string
+
+	// Property holds the value on per-country records.
+	Property string
+
+	// AggregateTotal and AggregateNew hold the accumulated and new values on
+	// the daily aggregate nodes. They are empty for metrics that cannot be
+	// summed over countries (e.g. rates), which are only served per country.
+	AggregateTotal string
+	AggregateNew   string
+}
+
+// Aggregated reports whether the metric has region and worldwide values.
+func (m Metric) Aggregated() bool {
+	return m.AggregateTotal != ""
+}
+
+var all = []Metric{
+	{
+		Name:           "cases",
+		Description:    "Confirmed COVID-19 cases",
+		Kind:           Cumulative,
+		Label:          "CovidCase",
+		Relationship:   "HAS_CASE",
+		Latest:         "LATEST_CASE",
+		Property:       "totalCases",
+		AggregateTotal: "totalCases",
+		AggregateNew:   "newCases",
+	},
+	{
+		Name:           "deaths",
+		Description:    "Confirmed COVID-19 deaths",
+		Kind:           Cumulative,
+		Label:          "CovidCase",
+		Relationship:   "HAS_CASE",
+		Latest:         "LATEST_CASE",
+		Property:       "totalDeaths",
+		AggregateTotal: "totalDeaths",
+		AggregateNew:   "newDeaths",
+	},
+	{
+		Name:           "vaccinated",
+		Description:    "People who received at least one vaccine dose",
+		Kind:           Cumulative,
+		Label:          "VaccinationStats",
+		Relationship:   "VACCINATED_ON",
+		Latest:         "LATEST_VACCINATION",
+		Property:       "totalVaccinated",
+		AggregateTotal: "totalVaccinated",
+		AggregateNew:   "newVaccinated",
+	},
+	{
+		Name:           "fully-vaccinated",
+		Description:    "People who received all doses of the initial vaccination protocol",
+		Kind:           Cumulative,
+		Label:          "VaccinationStats",
+		Relationship:   "VACCINATED_ON",
+		Latest:         "LATEST_VACCINATION",
+		Property:       "totalFullyVaccinated",
+		AggregateTotal: "totalFullyVaccinated",
+		AggregateNew:   "newFullyVaccinated",
+	},
+	{
+		Name:           "boosters",
+		Description:    "Booster doses administered beyond the initial protocol",
+		Kind:           Cumulative,
+		Label:          "VaccinationStats",
+		Relationship:   "VACCINATED_ON",
+		Latest:         "LATEST_VACCINATION",
+		Property:       "totalBoosters",
+		AggregateTotal: "totalBoosters",
+		AggregateNew:   "newBoosters",
+	},
+	{
+		Name:           "doses",
+		Description:    "Vaccine doses administered, of any kind",
+		Kind:           Cumulative,
+		Label:          "VaccinationStats",
+		Relationship:   "VACCINATED_ON",
+		Latest:         "LATEST_VACCINATION",
+		Property:       "totalDoses",
+		AggregateTotal: "totalDoses",
+		AggregateNew:   "newDoses",
+	},
+	{
+		Name:           "hospital-patients",
+		Description:    "COVID-19 patients in hospital",
+		Kind:           Gauge,
+		Label:          "HospitalStats",
+		Relationship:   "HAS_HOSPITAL_STATS",
+		Latest:         "LATEST_HOSPITAL_STATS",
+		Property:       "hospPatients",
+		AggregateTotal: "hospPatients",
+		AggregateNew:   "hospPatients",
+	},
+	{
+		Name:           "icu-patients",
+		Description:    "COVID-19 patients in intensive care units",
+		Kind:           Gauge,
+		Label:          "HospitalStats",
+		Relationship:   "HAS_HOSPITAL_STATS",
+		Latest:         "LATEST_HOSPITAL_STATS",
+		Property:       "icuPatients",
+		AggregateTotal: "icuPatients",
+		AggregateNew:   "icuPatients",
+	},
+	{
+		Name:           "weekly-hospital-admissions",
+		Description:    "COVID-19 hospital admissions in the 7 days up to the date",
+		Kind:           Gauge,
+		Label:          "HospitalStats",
+		Relationship:   "HAS_HOSPITAL_STATS",
+		Latest:         "LATEST_HOSPITAL_STATS",
+		Property:       "weeklyHospAdmissions",
+		AggregateTotal: "weeklyHospAdmissions",
+		AggregateNew:   "weeklyHospAdmissions",
+	},
+	{
+		Name:           "tests",
+		Description:    "COVID-19 tests performed",
+		Kind:           Cumulative,
+		Label:          "TestingStats",
+		Relationship:   "HAS_TESTING_STATS",
+		Latest:         "LATEST_TESTING_STATS",
+		Property:       "totalTests",
+		AggregateTotal: "totalTests",
+		AggregateNew:   "newTests",
+	},
+	{
+		Name:         "positive-rate",
+		Description:  "Share of COVID-19 tests that are positive (7-day rolling average)",
+		Kind:         Gauge,
+		Label:        "TestingStats",
+		Relationship: "HAS_TESTING_STATS",
+		Latest:       "LATEST_TESTING_STATS",
+		Property:     "positiveRate",
+	},
+}
+
+var byName = func() map[string]Metric {
+	index := make(map[string]Metric, len(all))
+	for _, m := range all {
+		index[m.Name] = m
+	}
+	return index
+}()
+
+// All returns every metric, in declaration order.
+func All() []Metric {
+	return append([]Metric(nil), all...)
+}
+
+// Lookup returns the metric with the given name.
+func Lookup(name string) (Metric, bool) {
+	m, ok := byName[name]
+	return m, ok
+}
+
+// MustLookup returns the metric with the given name, and panics when it is
+// not declared. It is meant for handlers bound to a metric at startup.
+func MustLookup(name string) Metric {
+	m, ok := byName[name]
+	if !ok {
+		panic("registry: unknown metric " + name)
+	}
+	return m
+}
+
+// Names returns the names of every metric, sorted.
+func Names() []string {
+	names := make([]string, 0, len(all))
+	for _, m := range all {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Labels returns the node labels holding per-country records, in
+// declaration order and without repetitions.
+func Labels() []string {
+	var labels []string
+	seen := map[string]bool{}
+	for _, m := range all {
+		if !seen[m.Label] {
+			seen[m.Label] = true
+			labels = append(labels, m.Label)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"regexp"
+	"testing"
+)
+
+var (
+	metricName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	identifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+func TestDeclarations(t *testing.T) {
+	seen := map[string]bool{}
+	for _, m := range All() {
+		if !metricName.MatchString(m.Name) {
+			t.Errorf("metric %q: name must be lowercase kebab-case", m.Name)
+		}
+		if seen[m.Name] {
+			t.Errorf("metric %q is declared twice", m.Name)
+		}
+		seen[m.Name] = true
+
+		if m.Kind != Cumulative && m.Kind != Gauge {
+			t.Errorf("metric %q: unknown kind %q", m.Name, m.Kind)
+		}
+		if m.Aggregated() != (m.AggregateNew != "") {
+			t.Errorf("metric %q: AggregateTotal and AggregateNew must be both set or both empty", m.Name)
+		}
+		// These names are written into Cypher queries, never passed as parameters.
+		names := map[string]string{
+			"Label":        m.Label,
+			"Relationship": m.Relationship,
+			"Latest":       m.Latest,
+			"Property":     m.Property,
+		}
+		if m.Aggregated() {
+			names["AggregateTotal"] = m.AggregateTotal
+			names["AggregateNew"] = m.AggregateNew
+		}
+		for field, value := range names {
+			if !identifier.MatchString(value) {
+				t.Errorf("metric %q: %s %q is not a valid identifier", m.Name, field, value)
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if m, ok := Lookup("cases"); !ok || m.Property != "totalCases" {
+		t.Errorf("expected cases to be stored in totalCases, got %+v", m)
+	}
+	if _, ok := Lookup("unknown"); ok {
+		t.Error("expected unknown metric not to be found")
+	}
+}
@@ -0,0 +1,24 @@
+// Package routes defines the application's URL routing.
+// This file declares all routes related to graph analytics,
+// and connects each endpoint to its corresponding handler.
+//
+// Specifically, it defines routes for the /analytics endpoints.
+
+package routes
+
+import "github.com/biiafranca/viralgraph/api/handlers/analytics"
+
+func analyticsRoutes() []Route {
+	return []Route{
+		// Louvain communities or connected components (ex: /v1/analytics/communities?projection=country-similarity)
+		get("/analytics/communities", analytics.HandleCommunities),
+
+		// Nodes ranked by centrality (ex: /v1/analytics/centrality?algorithm=pagerank&kind=vaccine)
+		get("/analytics/centrality", analytics.HandleCentrality),
+
+		// The same, also writing the results as node properties (ex: POST /v1/analytics/communities?algorithm=louvain).
+		// Added after versioning, so not served unversioned.
+		post("/analytics/communities", analytics.HandleCommunities).alias(),
+		post("/analytics/centrality", analytics.HandleCentrality).alias(),
+	}
+}
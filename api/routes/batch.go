@@ -0,0 +1,16 @@
+// Package routes defines the application's URL routing.
+// This file declares all routes related to batch lookups,
+// and connects each endpoint to its corresponding handler.
+//
+// Specifically, it defines routes for the /batch endpoints.
+
+package routes
+
+import "github.com/biiafranca/viralgraph/api/handlers/batch"
+
+func batchRoutes() []Route {
+	return []Route{
+		post("/batch/covid-stats", batch.HandleCovidStats),
+		post("/batch/vaccination", batch.HandleVaccination),
+	}
+}
@@ -0,0 +1,15 @@
+// Package routes defines the application's URL routing.
+// This file declares all routes related to countries,
+// and connects each endpoint to its corresponding handler.
+//
+// Specifically, it defines routes for the /countries endpoints.
+
+package routes
+
+import "github.com/biiafranca/viralgraph/api/handlers/countries"
+
+func countriesRoutes() []Route {
+	return []Route{
+		get("/countries/{country}/similar-portfolio", countries.HandleSimilarPortfolio),
+	}
+}
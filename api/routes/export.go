@@ -0,0 +1,15 @@
+// Package routes defines the application's URL routing.
+// This file declares all routes related to graph exports,
+// and connects each endpoint to its corresponding handler.
+//
+// Specifically, it defines routes for the /export endpoints.
+
+package routes
+
+import "github.com/biiafranca/viralgraph/api/handlers/export"
+
+func exportRoutes() []Route {
+	return []Route{
+		get("/export/graph", export.HandleExportGraph),
+	}
+}
@@ -0,0 +1,34 @@
+// Package routes defines the application's URL routing.
+// This file declares all routes of the endpoints serving a group of metrics
+// (see stats.GroupHandler), and connects each endpoint to its controller.
+//
+// Specifically, it defines routes for the /hospital-stats and /testing-stats endpoints.
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/biiafranca/viralgraph/api/handlers/hospitalstats"
+	"github.com/biiafranca/viralgraph/api/handlers/testingstats"
+)
+
+func groupStatsRoutes() []Route {
+	var table []Route
+	for _, group := range []struct {
+		base       string
+		controller http.HandlerFunc
+	}{
+		{"/hospital-stats", hospitalstats.HospitalStatsController},
+		{"/testing-stats", testingstats.TestingStatsController},
+	} {
+		table = append(table,
+			// Local stats, by country and date (ex: /v1/hospital-stats/FRA/2021-01-01)
+			get(group.base+"/{country}/{date}", group.controller),
+
+			// Global stats, by date (ex: /v1/testing-stats/2021-01-01)
+			get(group.base+"/{date}", group.controller),
+		)
+	}
+	return table
+}
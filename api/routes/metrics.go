@@ -0,0 +1,15 @@
+// Package routes defines the application's URL routing.
+// This file registers the Prometheus metrics endpoint.
+//
+// Specifically, it defines the /metrics route scraped by Prometheus.
+
+package routes
+
+import (
+	"github.com/biiafranca/viralgraph/api/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+func RegisterMetricsRoutes(r chi.Router) {
+	r.Method("GET", "/metrics", metrics.Handler())
+}
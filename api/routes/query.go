@@ -0,0 +1,15 @@
+// Package routes defines the application's URL routing.
+// This file declares the generic query route,
+// and connects it to its corresponding handler.
+//
+// Specifically, it defines the route for the /query endpoint.
+
+package routes
+
+import "github.com/biiafranca/viralgraph/api/handlers/query"
+
+func queryRoutes() []Route {
+	return []Route{
+		post("/query", query.HandleQuery),
+	}
+}
@@ -0,0 +1,37 @@
+// Package routes defines the application's URL routing.
+// This file declares the routes generated from the metric registry,
+// and connects each endpoint to its corresponding handler.
+//
+// Specifically, it defines the /stats endpoints of every registered metric.
+
+package routes
+
+import (
+	"github.com/biiafranca/viralgraph/api/handlers/stats"
+	"github.com/biiafranca/viralgraph/api/registry"
+)
+
+func statsRoutes() []Route {
+	// Available metrics (ex: /v1/stats)
+	table := []Route{get("/stats", stats.ListMetrics)}
+
+	for _, m := range registry.All() {
+		base := "/stats/" + m.Name
+
+		table = append(table,
+			// Value by date, worldwide or by region (ex: /v1/stats/cases/2021-01-01?region=Europe)
+			get(base+"/{date}", stats.ValueHandler(m)),
+
+			// Value by country and date (ex: /v1/stats/cases/BRA/2021-01-01?only-news=true)
+			get(base+"/{country}/{date}", stats.ValueHandler(m)),
+
+			// Daily series (ex: /v1/stats/cases/series/BRA?from=2021-01-01&to=2021-03-31)
+			get(base+"/series", stats.SeriesHandler(m)),
+			get(base+"/series/{country}", stats.SeriesHandler(m)),
+
+			// Countries ranked by value (ex: /v1/stats/cases/ranking/2021-01-01?limit=10)
+			get(base+"/ranking/{date}", stats.RankingHandler(m)),
+		)
+	}
+	return table
+}
@@ -0,0 +1,151 @@
+// Package routes defines the application's URL routing.
+// This file holds the route table and mounts it under a version prefix.
+//
+// Every API route is declared once, as a Route, by the file of its domain.
+// The routes of a version are served under its prefix (e.g. /v1). The
+// paths served before versioning are kept as deprecated aliases, which
+// answer with Deprecation, Sunset and Link headers pointing to the
+// versioned path. A new version can start from the previous table and
+// replace only the routes whose responses change (see Override).
+
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Route is an endpoint of the route table.
+type Route struct {
+	Method  string
+	Pattern string
+	Handler http.Handler
+	// Aliases are the unversioned patterns the route was served on before
+	// versioning. They keep working until the sunset, but are deprecated.
+	Aliases []string
+}
+
+// Version is a set of routes served under a prefix.
+type Version struct {
+	Prefix string
+	Routes []Route
+}
+
+// get declares a GET route, also served unversioned as a deprecated alias.
+func get(pattern string, handler http.HandlerFunc) Route {
+	return Route{Method: http.MethodGet, Pattern: pattern, Handler: handler, Aliases: []string{pattern}}
+}
+
+// post declares a POST route, also served unversioned as a deprecated alias.
+func post(pattern string, handler http.HandlerFunc) Route {
+	return Route{Method: http.MethodPost, Pattern: pattern, Handler: handler, Aliases: []string{pattern}}
+}
+
+// alias replaces the aliases of a route whose unversioned path differs.
+func (route Route) alias(patterns ...string) Route {
+	route.Aliases = patterns
+	return route
+}
+
+var (
+	// AliasDeprecation is when the unversioned aliases were deprecated.
+	AliasDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	// AliasSunset is when the unversioned aliases stop being served.
+	AliasSunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// V1 returns the routes of the first version of the API.
+func V1() Version {
+	var table []Route
+	for _, domain := range [][]Route{
+		covidStatsRoutes(),
+		vaccinationRoutes(),
+		groupStatsRoutes(),
+		vaccinesRoutes(),
+		countriesRoutes(),
+		analyticsRoutes(),
+		exportRoutes(),
+		batchRoutes(),
+		queryRoutes(),
+		statsRoutes(),
+	} {
+		table = append(table, domain...)
+	}
+	return Version{Prefix: "/v1", Routes: table}
+}
+
+// Versions lists the versions served by the API, oldest first.
+func Versions() []Version {
+	return []Version{V1()}
+}
+
+// Override returns the routes of base with the changes applied: a change
+// replaces the route with the same method and pattern, or is added. The
+// result has no aliases, since the unversioned paths keep pointing to the
+// version that declared them.
+func Override(base []Route, changes ...Route) []Route {
+	table := make([]Route, 0, len(base)+len(changes))
+	for _, route := range base {
+		route.Aliases = nil
+		table = append(table, route)
+	}
+	for _, change := range changes {
+		change.Aliases = nil
+		replaced := false
+		for i, route := range table {
+			if route.Method == change.Method && route.Pattern == change.Pattern {
+				table[i], replaced = change, true
+				break
+			}
+		}
+		if !replaced {
+			table = append(table, change)
+		}
+	}
+	return table
+}
+
+// Mount registers the routes of a version under its prefix, and their
+// aliases with the deprecation headers. The middlewares (e.g. the response
+// cache) run after routing, inside the deprecation headers.
+func Mount(r chi.Router, v Version, middlewares ...func(http.Handler) http.Handler) {
+	versioned := r.With(middlewares...)
+	for _, route := range v.Routes {
+		versioned.Method(route.Method, v.Prefix+route.Pattern, route.Handler)
+	}
+
+	for _, route := range v.Routes {
+		deprecated := r.With(append([]func(http.Handler) http.Handler{deprecation(v.Prefix + route.Pattern)}, middlewares...)...)
+		for _, alias := range route.Aliases {
+			deprecated.Method(route.Method, alias, route.Handler)
+		}
+	}
+}
+
+var patternParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// deprecation marks the responses of an alias as deprecated (RFC 9745),
+// announces its sunset (RFC 8594) and links to the successor pattern,
+// filled with the request's path parameters and query string.
+func deprecation(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			target := patternParam.ReplaceAllStringFunc(successor, func(param string) string {
+				return url.PathEscape(chi.URLParam(r, param[1:len(param)-1]))
+			})
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", AliasDeprecation.Unix()))
+			w.Header().Set("Sunset", AliasSunset.Format(http.TimeFormat))
+			w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, target))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
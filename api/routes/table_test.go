@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func echo(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+func TestMount_Aliases(t *testing.T) {
+	r := chi.NewRouter()
+	Mount(r, Version{Prefix: "/v1", Routes: []Route{
+		get("/things/{id}", echo("thing")).alias("/thing/{id}"),
+		get("/others", echo("others")),
+	}})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/things/7", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "thing" {
+		t.Fatalf("expected the versioned route, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Deprecation") != "" {
+		t.Errorf("expected no Deprecation header on the versioned route")
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/thing/7?limit=5", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "thing" {
+		t.Fatalf("expected the alias to be served, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Deprecation"); !strings.HasPrefix(got, "@") {
+		t.Errorf("expected a Deprecation date, got %q", got)
+	}
+	if got := rec.Header().Get("Sunset"); got != AliasSunset.Format(http.TimeFormat) {
+		t.Errorf("expected the Sunset date, got %q", got)
+	}
+	if got, want := rec.Header().Get("Link"), `</v1/things/7?limit=5>; rel="successor-version"`; got != want {
+		t.Errorf("expected Link %s, got %s", want, got)
+	}
+
+	// The alias replaces the unversioned path of the route
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/7", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for the replaced path, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/others", nil))
+	if got, want := rec.Header().Get("Link"), `</v1/others>; rel="successor-version"`; got != want {
+		t.Errorf("expected Link %s, got %s", want, got)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	base := []Route{get("/things", echo("v1")), get("/others", echo("v1"))}
+	table := Override(base, get("/things", echo("v2")), post("/things", echo("v2")))
+
+	r := chi.NewRouter()
+	Mount(r, Version{Prefix: "/v1", Routes: base})
+	Mount(r, Version{Prefix: "/v2", Routes: table})
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/v1/things", "v1"},
+		{http.MethodGet, "/v2/things", "v2"},
+		{http.MethodGet, "/v2/others", "v1"},
+		{http.MethodPost, "/v2/things", "v2"},
+		// Unversioned paths stay with the version that declared them
+		{http.MethodGet, "/things", "v1"},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Body.String() != tc.body {
+			t.Errorf("%s %s: expected %q, got %q", tc.method, tc.path, tc.body, rec.Body.String())
+		}
+	}
+}
+
+func TestV1(t *testing.T) {
+	r := chi.NewRouter()
+	Mount(r, V1())
+
+	routes := map[string]bool{}
+	chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[method+" "+route] = true
+		return nil
+	})
+	for _, route := range []string{
+		"GET /v1/covid-stats/{country}/{date}",
+		"GET /covid-stats/{country}/{date}",
+		"GET /v1/vaccinations/{date}",
+		"GET /v1/hospital-stats/{country}/{date}",
+		"GET /testing-stats/{date}",
+		"GET /vaccination/{date}",
+		"POST /v1/query",
+		"POST /v1/analytics/communities",
+		"POST /v1/analytics/centrality",
+		"GET /v1/stats/cases/series/{country}",
+	} {
+		if !routes[route] {
+			t.Errorf("expected route %s", route)
+		}
+	}
+	// Routes added after versioning have no unversioned alias
+	if routes["POST /analytics/communities"] {
+		t.Error("expected no unversioned alias for POST /analytics/communities")
+	}
+}
@@ -1,17 +1,18 @@
 // Package routes defines the application's URL routing.
-// This file registers all routes related to COVID-19 vaccination,
+// This file declares all routes related to COVID-19 vaccination,
 // and connects each endpoint to its corresponding controller.
 //
-// Specifically, it defines routes for the /vaccination endpoints.
+// Specifically, it defines routes for the /vaccinations endpoints.
 
 package routes
 
-import (
-	"github.com/biiafranca/viralgraph/api/handlers/vaccination"
-	"github.com/go-chi/chi/v5"
-)
+import "github.com/biiafranca/viralgraph/api/handlers/vaccination"
 
-func RegisterVaccinationRoutes(r chi.Router) {
-	r.Get("/vaccination/{country}/{date}", vaccination.VaccinationController)
-	r.Get("/vaccination/{date}", vaccination.VaccinationController)
+func vaccinationRoutes() []Route {
+	// Served as /vaccination before versioning, while the docs said
+	// /vaccinations; the versioned path follows the docs.
+	return []Route{
+		get("/vaccinations/{country}/{date}", vaccination.VaccinationController).alias("/vaccination/{country}/{date}"),
+		get("/vaccinations/{date}", vaccination.VaccinationController).alias("/vaccination/{date}"),
+	}
 }
@@ -1,19 +1,24 @@
 // Package routes defines the application's URL routing.
-// This file registers all routes related to COVID-19 vaccines,
+// This file declares all routes related to COVID-19 vaccines,
 // and connects each endpoint to its corresponding handler.
 //
 // Specifically, it defines routes for the /vaccines endpoints.
 
 package routes
 
-import (
-	"github.com/biiafranca/viralgraph/api/handlers/vaccines"
-	"github.com/go-chi/chi/v5"
-)
+import "github.com/biiafranca/viralgraph/api/handlers/vaccines"
 
-func RegisterUsedVaccinesRoutes(r chi.Router) {
-	r.Get("/vaccines", vaccines.HandleVaccines)
-	r.Get("/vaccines/used-in/{country}", vaccines.HandleUsedInCountry)
-	r.Get("/vaccines/first-use", vaccines.HandleFirstUse)
-	r.Get("/vaccines/{vaccineID}/used-by", vaccines.HandleUsedBy)
+func vaccinesRoutes() []Route {
+	return []Route{
+		get("/vaccines", vaccines.HandleVaccines),
+		get("/vaccines/used-in/{country}", vaccines.HandleUsedInCountry),
+		get("/vaccines/used-in/{country}/doses", vaccines.HandleDosesInCountry),
+		get("/vaccines/first-use", vaccines.HandleFirstUse),
+		get("/vaccines/search", vaccines.HandleSearchVaccines),
+		get("/vaccines/{vaccineID}", vaccines.HandleVaccine),
+		get("/vaccines/{vaccineID}/used-by", vaccines.HandleUsedBy),
+		get("/vaccines/{vaccineID}/doses", vaccines.HandleVaccineDoses),
+		get("/vaccines/{vaccineID}/adoption", vaccines.HandleAdoption),
+		get("/vaccines/{vaccineID}/co-used", vaccines.HandleCoUsed),
+	}
 }
@@ -0,0 +1,143 @@
+// Package tracing configures OpenTelemetry tracing for the API.
+//
+// It creates one span per HTTP request and provides the tracer used by the
+// Neo4j layer to create one span per Cypher execution.
+//
+// The exporter is selected with OTEL_TRACES_EXPORTER:
+//   - "otlp":   OTLP over HTTP, configured by the standard OTEL_EXPORTER_OTLP_*
+//     variables (e.g. OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4318)
+//   - "stdout": pretty-printed spans on standard output, for development
+//   - "none" or unset: tracing disabled
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/biiafranca/viralgraph/api/metrics"
+	"github.com/biiafranca/viralgraph/api/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/biiafranca/viralgraph/api"
+	defaultServiceName  = "viralgraph-api"
+)
+
+// Setup installs the global tracer provider and propagator according to
+// OTEL_TRACES_EXPORTER. The returned function flushes and stops the
+// exporter, and must be called before the process exits.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch kind := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")); kind {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q (use otlp, stdout or none)", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating trace exporter: %w", err)
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for the API's own spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Middleware starts a server span for each request, continuing any trace
+// propagated by the caller. The span is named after the chi route pattern
+// once routing is done (e.g. "GET /covid-stats/{date}").
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := utils.NewStatusRecorder(w)
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		route := metrics.RoutePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(sw.Status),
+		)
+		if sw.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.Status))
+		}
+	})
+}
+
+// StartQuery starts a client span for a named Cypher execution.
+// The caller ends it with EndQuery once the records have been fetched.
+func StartQuery(ctx context.Context, name string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "neo4j "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String("neo4j"),
+			semconv.DBOperationName(name),
+		),
+	)
+}
+
+// EndQuery records the outcome of a Cypher execution on its span and ends it.
+func EndQuery(span trace.Span, database string, rows int, err error) {
+	if database != "" {
+		span.SetAttributes(semconv.DBNamespace(database))
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", rows))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestMiddleware_NamesSpanAfterRoute(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/covid-stats/{date}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := StartQuery(r.Context(), "covidstats.new.worldwide.current")
+		EndQuery(span, "neo4j", 1, nil)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/covid-stats/2021-07-31", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	query, request := spans[0], spans[1]
+	if request.Name() != "GET /covid-stats/{date}" {
+		t.Errorf("expected request span named after route, got %q", request.Name())
+	}
+	if query.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("expected query span to be a child of the request span")
+	}
+}
+
+func TestEndQuery_RecordsError(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	_, span := StartQuery(context.Background(), "vaccines.list")
+	EndQuery(span, "", 0, errors.New("boom"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Error {
+		t.Errorf("expected one span with error status, got %+v", spans)
+	}
+}
@@ -1,20 +1,147 @@
 // Package utils offers helper functions for HTTP responses.
 //
-// This file handles standardized error formatting in JSON.
+// This file handles error responses, written as RFC 7807 problem details
+// (application/problem+json). Every problem has a machine-readable code,
+// which also names its type, so that clients can tell e.g. an unknown
+// country from a known country without data on a date.
 
 package utils
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// Problem codes.
+const (
+	CodeInvalidParameter = "invalid-parameter"
+	CodeMissingParameter = "missing-parameter"
+	CodeUnknownParameter = "unknown-parameter"
+	CodeInvalidBody      = "invalid-body"
+	CodeFutureDate       = "future-date"
+	CodeUnknownCountry   = "unknown-country"
+	CodeUnknownRegion    = "unknown-region"
+	CodeUnknownVaccine   = "unknown-vaccine"
+	CodeNoData           = "no-data"
+	CodeNotFound         = "not-found"
+	CodeMethodNotAllowed = "method-not-allowed"
+	CodeQueryTimeout     = "query-timeout"
+	CodeDatabaseError    = "database-error"
+	CodeInternalError    = "internal-error"
+)
+
+// ProblemTypePrefix prefixes the code in the type URI of problems.
+const ProblemTypePrefix = "urn:viralgraph:problem:"
+
+type problemType struct {
+	status int
+	title  string
+}
+
+var problemTypes = map[string]problemType{
+	CodeInvalidParameter: {http.StatusBadRequest, "Invalid parameter"},
+	CodeMissingParameter: {http.StatusBadRequest, "Missing parameter"},
+	CodeUnknownParameter: {http.StatusBadRequest, "Unknown parameter"},
+	CodeInvalidBody:      {http.StatusBadRequest, "Invalid request body"},
+	CodeFutureDate:       {http.StatusNotFound, "Date in the future"},
+	CodeUnknownCountry:   {http.StatusNotFound, "Unknown country"},
+	CodeUnknownRegion:    {http.StatusNotFound, "Unknown region"},
+	CodeUnknownVaccine:   {http.StatusNotFound, "Unknown vaccine"},
+	CodeNoData:           {http.StatusNotFound, "No data"},
+	CodeNotFound:         {http.StatusNotFound, "Not found"},
+	CodeMethodNotAllowed: {http.StatusMethodNotAllowed, "Method not allowed"},
+	CodeQueryTimeout:     {http.StatusGatewayTimeout, "Query timeout"},
+	CodeDatabaseError:    {http.StatusInternalServerError, "Database error"},
+	CodeInternalError:    {http.StatusInternalServerError, "Internal error"},
+}
+
+// Problem is an RFC 7807 problem details object, extended with the problem
+// code and the offending parameter, if any. It is also an error, so that
+// parsers and lookups can return it for handlers to write as is.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+	Param  string `json:"param,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+// NewProblem returns a problem of the given code.
+func NewProblem(code, detail string) *Problem {
+	t, ok := problemTypes[code]
+	if !ok {
+		code, t = CodeInternalError, problemTypes[CodeInternalError]
+	}
+	return &Problem{
+		Type:   ProblemTypePrefix + code,
+		Title:  t.title,
+		Status: t.status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// WithParam sets the offending parameter (a query, path or body field).
+func (p *Problem) WithParam(param string) *Problem {
+	p.Param = param
+	return p
+}
+
+// InvalidParam returns an invalid-parameter problem for the parameter.
+func InvalidParam(param, detail string) *Problem {
+	return NewProblem(CodeInvalidParameter, detail).WithParam(param)
+}
+
+// MissingParam returns a missing-parameter problem for the parameter.
+func MissingParam(param, detail string) *Problem {
+	return NewProblem(CodeMissingParameter, detail).WithParam(param)
+}
+
+// UnknownCountry returns the problem reported for a country code that
+// matches no country.
+func UnknownCountry(code string) *Problem {
+	return NewProblem(CodeUnknownCountry, "No country found for code "+code).WithParam("country")
+}
+
+// DatabaseError returns the problem reported when a query fails.
+func DatabaseError() *Problem {
+	return NewProblem(CodeDatabaseError, "Failed to query database")
+}
+
+// AsProblem converts err to a problem: problems are returned as they are,
+// deadlines become query timeouts and any other error an internal error.
+func AsProblem(err error) *Problem {
+	var p *Problem
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewProblem(CodeQueryTimeout, "The query took too long")
+	}
+	return NewProblem(CodeInternalError, "Failed to process the request")
+}
+
+// RespondWithError writes err as a problem (see AsProblem).
+func RespondWithError(w http.ResponseWriter, err error) {
+	p := AsProblem(err)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// NotFound answers requests for unknown routes.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	RespondWithError(w, NewProblem(CodeNotFound, "No endpoint at "+r.URL.Path))
 }
 
-func RespondWithError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+// MethodNotAllowed answers requests with a method the route does not serve.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	RespondWithError(w, NewProblem(CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path))
 }
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondWithError_Problem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RespondWithError(rec, fmt.Errorf("parsing: %w", InvalidParam("limit", "Invalid limit")))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("expected application/problem+json, got %q", got)
+	}
+	var p Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	expected := Problem{
+		Type:   "urn:viralgraph:problem:invalid-parameter",
+		Title:  "Invalid parameter",
+		Status: http.StatusBadRequest,
+		Detail: "Invalid limit",
+		Code:   CodeInvalidParameter,
+		Param:  "limit",
+	}
+	if p != expected {
+		t.Errorf("expected %+v, got %+v", expected, p)
+	}
+}
+
+func TestAsProblem(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		code   string
+		status int
+	}{
+		"unknown country": {UnknownCountry("XYZ"), CodeUnknownCountry, http.StatusNotFound},
+		"no data":         {NewProblem(CodeNoData, "No data"), CodeNoData, http.StatusNotFound},
+		"deadline":        {context.DeadlineExceeded, CodeQueryTimeout, http.StatusGatewayTimeout},
+		"other error":     {errors.New("boom"), CodeInternalError, http.StatusInternalServerError},
+		"unknown code":    {NewProblem("teapot", "?"), CodeInternalError, http.StatusInternalServerError},
+	}
+	for name, test := range tests {
+		p := AsProblem(test.err)
+		if p.Code != test.code || p.Status != test.status {
+			t.Errorf("%s: expected %s (%d), got %s (%d)", name, test.code, test.status, p.Code, p.Status)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NotFound(rec, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	var p Problem
+	json.NewDecoder(rec.Body).Decode(&p)
+	if rec.Code != http.StatusNotFound || p.Code != CodeNotFound {
+		t.Errorf("expected a not-found problem, got %d %+v", rec.Code, p)
+	}
+}
@@ -0,0 +1,112 @@
+// Package utils offers helper functions for HTTP responses.
+//
+// This file implements cursor pagination and sorting for list endpoints.
+// Lists are sorted by a whitelisted field (sort=name, or sort=-name for
+// descending order) with the item's key as tie-breaker, and a page starts
+// right after the item encoded in the cursor. Unlike offsets, cursors stay
+// valid when items are added before them. The parameters are parsed by
+// package param (see param.Page).
+
+package utils
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// PageParams are the parsed pagination and sorting query parameters.
+type PageParams struct {
+	Limit int
+	Sort  string // the sort field, without the "-" prefix
+	Desc  bool
+	After *Cursor
+}
+
+// Cursor identifies the last item of a page, encoded as base64 JSON in the
+// cursor query parameter. It records the sort it was issued for, since it
+// means nothing in another order.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Key   string `json:"k"`
+}
+
+// Pagination is the pagination envelope of a list response, with links
+// relative to the API root.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Self       string `json:"self"`
+	Next       string `json:"next,omitempty"`
+}
+
+// sort returns the sort as written in the query string.
+func (p PageParams) sort() string {
+	if p.Desc {
+		return "-" + p.Sort
+	}
+	return p.Sort
+}
+
+// Paginate sorts the items by the value of the sort field (compared as
+// strings, so numbers must be zero-padded) and then by key, and returns
+// the page after the cursor with the cursor of the next page, if any.
+func Paginate[T any](items []T, p PageParams, value func(item T, sort string) string, key func(item T) string) ([]T, string) {
+	compare := func(aValue, aKey, bValue, bKey string) int {
+		order := cmp.Compare(aValue, bValue)
+		if p.Desc {
+			order = -order
+		}
+		if order != 0 {
+			return order
+		}
+		return cmp.Compare(aKey, bKey)
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		return compare(value(a, p.Sort), key(a), value(b, p.Sort), key(b))
+	})
+
+	start := 0
+	if p.After != nil {
+		start = len(items)
+		for i, item := range items {
+			if compare(value(item, p.Sort), key(item), p.After.Value, p.After.Key) > 0 {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+p.Limit, len(items))
+	if end == len(items) {
+		return items[start:end], ""
+	}
+
+	last := items[end-1]
+	data, _ := json.Marshal(Cursor{Sort: p.sort(), Value: value(last, p.Sort), Key: key(last)})
+	return items[start:end], base64.RawURLEncoding.EncodeToString(data)
+}
+
+// NewPagination builds the pagination envelope of a page and sets the
+// matching Link header, so that CSV and NDJSON clients can follow it too.
+func NewPagination(w http.ResponseWriter, r *http.Request, p PageParams, nextCursor string) Pagination {
+	pagination := Pagination{
+		Limit:      p.Limit,
+		Sort:       p.sort(),
+		NextCursor: nextCursor,
+		Self:       r.URL.RequestURI(),
+	}
+	if nextCursor != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		next.RawQuery = query.Encode()
+		pagination.Next = next.RequestURI()
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, pagination.Next))
+	}
+	return pagination
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	Name string
+	Date string
+}
+
+func itemValue(item testItem, sort string) string {
+	if sort == "date" {
+		return item.Date
+	}
+	return item.Name
+}
+
+func itemKey(item testItem) string { return item.Name }
+
+func testItems() []testItem {
+	return []testItem{
+		{"c", "2021-01-02"},
+		{"a", "2021-01-03"},
+		{"d", "2021-01-01"},
+		{"b", "2021-01-02"},
+	}
+}
+
+// nextPage returns the page following the cursor, decoded as package
+// param does.
+func nextPage(p PageParams, next string) PageParams {
+	data, _ := base64.RawURLEncoding.DecodeString(next)
+	var c Cursor
+	json.Unmarshal(data, &c)
+	p.After = &c
+	return p
+}
+
+func TestPaginate_Cursor(t *testing.T) {
+	var names []string
+	p := PageParams{Limit: 2, Sort: "date", Desc: true}
+	target := "/items?sort=-date&limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+		page, next := Paginate(testItems(), p, itemValue, itemKey)
+		for _, item := range page {
+			names = append(names, item.Name)
+		}
+
+		rec := httptest.NewRecorder()
+		pagination := NewPagination(rec, httptest.NewRequest(http.MethodGet, target, nil), p, next)
+		if pagination.Sort != "-date" || pagination.Limit != 2 {
+			t.Errorf("unexpected pagination %+v", pagination)
+		}
+		if (next == "") != (rec.Header().Get("Link") == "") {
+			t.Errorf("expected a Link header only with a next page, got %q", rec.Header().Get("Link"))
+		}
+		if next == "" {
+			break
+		}
+		if want := "cursor=" + url.QueryEscape(next); !strings.Contains(pagination.Next, want) {
+			t.Errorf("expected %q in the next link, got %q", want, pagination.Next)
+		}
+		target, p = pagination.Next, nextPage(p, next)
+	}
+
+	// Descending dates, ties broken by ascending name.
+	if got, want := names, []string{"a", "b", "c", "d"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPaginate_CursorRecordsSort(t *testing.T) {
+	p := PageParams{Limit: 1, Sort: "name"}
+	_, next := Paginate(testItems(), p, itemValue, itemKey)
+	if got := nextPage(p, next).After; got.Sort != "name" || got.Key != "a" {
+		t.Errorf("expected a cursor after a sorted by name, got %+v", got)
+	}
+}
@@ -0,0 +1,104 @@
+// Package utils offers helper functions for HTTP responses.
+//
+// This file writes successful responses in the format negotiated with the
+// client: JSON (the default), CSV or NDJSON. The format query parameter
+// (json, csv or ndjson) takes precedence over the Accept header.
+
+package utils
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+var mediaTypes = map[string]string{
+	"application/json":     FormatJSON,
+	"text/csv":             FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+	"application/ndjson":   FormatNDJSON,
+}
+
+var contentTypes = map[string]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// Negotiate returns the response format requested by the format query
+// parameter or, without it, by the Accept header. Media types the API
+// does not produce are skipped, falling back to JSON.
+func Negotiate(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, ok := contentTypes[format]; !ok {
+			return "", InvalidParam("format", "Invalid format. Use json, csv or ndjson.")
+		}
+		return format, nil
+	}
+
+	type candidate struct {
+		format string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{format, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) > 0 {
+		return candidates[0].format, nil
+	}
+	return FormatJSON, nil
+}
+
+// Respond writes v with status 200 in the negotiated format.
+//
+// CSV and NDJSON write one row per line. The rows of a response are the
+// elements of its field tagged `table:"rows"`, preceded by the response's
+// scalar fields (e.g. the country of a series), and rows can hold rows of
+// their own. Responses without such a field are written as a single row.
+// Rows are written as they are encoded, without building the whole
+// document in memory.
+func Respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	format, err := Negotiate(r)
+	if err != nil {
+		RespondWithError(w, err)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", contentTypes[format])
+	switch format {
+	case FormatCSV:
+		writeCSV(w, v)
+	case FormatNDJSON:
+		writeNDJSON(w, v)
+	default:
+		json.NewEncoder(w).Encode(v)
+	}
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testPoint struct {
+	Date  string  `json:"date"`
+	Total int64   `json:"total"`
+	Share float64 `json:"share,omitempty"`
+}
+
+type testSeries struct {
+	Country string      `json:"country"`
+	Points  []testPoint `json:"points" table:"rows"`
+}
+
+type testSeriesResponse struct {
+	Context string       `json:"context"`
+	Tags    []string     `json:"tags"`
+	Series  []testSeries `json:"series" table:"rows"`
+}
+
+type testStats struct {
+	Country string   `json:"country"`
+	Rate    *float64 `json:"rate,omitempty"`
+}
+
+type testResult struct {
+	Index int    `json:"index"`
+	Error string `json:"error,omitempty"`
+	*testStats
+}
+
+type testBatch struct {
+	Results []testResult `json:"results" table:"rows"`
+}
+
+type testTable struct {
+	Columns []string                 `json:"columns" table:"columns"`
+	Rows    []map[string]interface{} `json:"rows" table:"rows"`
+}
+
+func respond(t *testing.T, target, accept string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	Respond(rec, r, v)
+	return rec
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, tc := range []struct {
+		target, accept, expected string
+	}{
+		{"/", "", FormatJSON},
+		{"/", "text/html,application/xhtml+xml,*/*;q=0.8", FormatJSON},
+		{"/", "text/csv", FormatCSV},
+		{"/", "application/json;q=0.5, application/x-ndjson", FormatNDJSON},
+		{"/", "text/csv;q=0, application/json", FormatJSON},
+		{"/?format=CSV", "application/x-ndjson", FormatCSV},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		r.Header.Set("Accept", tc.accept)
+		if format, err := Negotiate(r); err != nil || format != tc.expected {
+			t.Errorf("%s with Accept %q: expected %s, got %s (%v)", tc.target, tc.accept, tc.expected, format, err)
+		}
+	}
+
+	if rec := respond(t, "/?format=xml", "", testStats{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown format, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestRespond_CSV(t *testing.T) {
+	response := testSeriesResponse{
+		Context: "vaccine",
+		Tags:    []string{"a", "b"},
+		Series: []testSeries{
+			{Country: "BRA", Points: []testPoint{{"2021-01-17", 10, 0.5}, {"2021-01-18", 12, 0}}},
+			{Country: "CHL", Points: []testPoint{{"2021-01-20", 3, 1}}},
+		},
+	}
+	rec := respond(t, "/", "text/csv", response)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("expected a CSV content type, got %q", ct)
+	}
+	expected := "context,country,date,total,share\n" +
+		"vaccine,BRA,2021-01-17,10,0.5\n" +
+		"vaccine,BRA,2021-01-18,12,0\n" +
+		"vaccine,CHL,2021-01-20,3,1\n"
+	if rec.Body.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rec.Body.String())
+	}
+
+	// The header is written even without rows.
+	rec = respond(t, "/?format=csv", "", testSeriesResponse{Context: "vaccine"})
+	if rec.Body.String() != "context,country,date,total,share\n" {
+		t.Errorf("expected only the header, got %q", rec.Body.String())
+	}
+}
+
+func TestRespond_EmbeddedAndSingleRow(t *testing.T) {
+	rate := 0.25
+	batch := testBatch{Results: []testResult{
+		{Index: 0, testStats: &testStats{Country: "BRA", Rate: &rate}},
+		{Index: 1, Error: "Invalid country"},
+	}}
+	rec := respond(t, "/?format=csv", "", batch)
+	expected := "index,error,country,rate\n0,,BRA,0.25\n1,Invalid country,,\n"
+	if rec.Body.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rec.Body.String())
+	}
+
+	rec = respond(t, "/?format=ndjson", "", batch)
+	expected = `{"index":0,"country":"BRA","rate":0.25}` + "\n" + `{"index":1,"error":"Invalid country"}` + "\n"
+	if rec.Body.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rec.Body.String())
+	}
+
+	rec = respond(t, "/?format=csv", "", testStats{Country: "BRA"})
+	if rec.Body.String() != "country,rate\nBRA,\n" {
+		t.Errorf("expected a single row, got %q", rec.Body.String())
+	}
+}
+
+func TestRespond_MapRows(t *testing.T) {
+	table := testTable{
+		Columns: []string{"country", "cases"},
+		Rows: []map[string]interface{}{
+			{"country": "BRA", "cases": int64(100)},
+			{"country": "ARG", "cases": nil},
+		},
+	}
+	rec := respond(t, "/", "text/csv", table)
+	if expected := "country,cases\nBRA,100\nARG,\n"; rec.Body.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rec.Body.String())
+	}
+
+	rec = respond(t, "/", "application/x-ndjson", table)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 || lines[0] != `{"country":"BRA","cases":100}` || lines[1] != `{"country":"ARG","cases":null}` {
+		t.Errorf("unexpected NDJSON %q", rec.Body.String())
+	}
+}
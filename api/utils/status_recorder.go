@@ -0,0 +1,36 @@
+// Package utils offers helper functions for HTTP responses.
+//
+// This file provides a response writer wrapper used by middlewares
+// that need to know the status code and size of a response.
+
+package utils
+
+import "net/http"
+
+// StatusRecorder wraps an http.ResponseWriter and records the status code
+// and number of body bytes written through it.
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status int
+	Bytes  int
+}
+
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (sr *StatusRecorder) WriteHeader(code int) {
+	sr.Status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *StatusRecorder) Write(b []byte) (int, error) {
+	n, err := sr.ResponseWriter.Write(b)
+	sr.Bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (sr *StatusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -0,0 +1,307 @@
+// Package utils offers helper functions for HTTP responses.
+//
+// This file flattens responses into rows for the CSV and NDJSON formats.
+// Columns are named after the JSON field names, so every format uses the
+// same names.
+
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cell is a named value of a row.
+type cell struct {
+	name      string
+	value     reflect.Value
+	omitEmpty bool
+}
+
+// field is an exported struct field as seen by encoding/json.
+type field struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	table     string // "rows", "columns" or ""
+}
+
+// fieldsOf lists the fields of a struct type in order, with the fields of
+// embedded structs promoted as encoding/json does.
+func fieldsOf(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for _, embedded := range fieldsOf(ft) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     []int{i},
+			typ:       f.Type,
+			omitEmpty: strings.Contains(options, "omitempty"),
+			table:     f.Tag.Get("table"),
+		})
+	}
+	return fields
+}
+
+// scalar reports whether values of the type fit in a single cell without
+// encoding, and so can repeat as context on every row.
+func scalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// indirect dereferences pointers and interfaces, returning an invalid
+// value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+	return v
+}
+
+// merge appends the row's cells to the context, dropping context cells
+// the row has a cell of the same name for.
+func merge(context, row []cell) []cell {
+	merged := make([]cell, 0, len(context)+len(row))
+	for _, c := range context {
+		if !slices.ContainsFunc(row, func(r cell) bool { return r.name == c.name }) {
+			merged = append(merged, c)
+		}
+	}
+	return append(merged, row...)
+}
+
+// table locates the rows (and, for map rows, the column names) of a
+// struct type.
+func table(fields []field) (rows, columns *field) {
+	for i := range fields {
+		switch fields[i].table {
+		case "rows":
+			rows = &fields[i]
+		case "columns":
+			columns = &fields[i]
+		}
+	}
+	return rows, columns
+}
+
+// header returns the column names of the rows of a value of type t, after
+// the context columns. v is needed for map rows, whose columns come from
+// the value's columns field.
+func header(t reflect.Type, v reflect.Value, context []cell) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var cells []cell
+	if t.Kind() != reflect.Struct {
+		cells = merge(context, []cell{{name: "value"}})
+	} else {
+		fields := fieldsOf(t)
+		rows, columns := table(fields)
+		if rows == nil {
+			for _, f := range fields {
+				cells = append(cells, cell{name: f.name})
+			}
+			cells = merge(context, cells)
+		} else {
+			var own []cell
+			for _, f := range fields {
+				if f.table == "" && scalar(f.typ) {
+					own = append(own, cell{name: f.name})
+				}
+			}
+			elem := rows.typ.Elem()
+			if elem.Kind() == reflect.Map {
+				var names []cell
+				if v = indirect(v); v.IsValid() && columns != nil {
+					for _, name := range v.FieldByIndex(columns.index).Interface().([]string) {
+						names = append(names, cell{name: name})
+					}
+				}
+				cells = merge(merge(context, own), names)
+			} else {
+				return header(elem, reflect.Value{}, merge(context, own))
+			}
+		}
+	}
+
+	names := make([]string, len(cells))
+	for i, c := range cells {
+		names[i] = c.name
+	}
+	return names
+}
+
+// eachRow calls fn with the cells of each row of v, after the context.
+func eachRow(v reflect.Value, context []cell, fn func([]cell) error) error {
+	v = indirect(v)
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return fn(merge(context, []cell{{name: "value", value: v}}))
+	}
+
+	fields := fieldsOf(v.Type())
+	rows, columns := table(fields)
+	var own []cell
+	for _, f := range fields {
+		if rows != nil && (f.table != "" || !scalar(f.typ)) {
+			continue
+		}
+		// Fields of a nil embedded struct are empty, and left out of NDJSON
+		// as encoding/json does.
+		value, err := v.FieldByIndexErr(f.index)
+		own = append(own, cell{name: f.name, value: value, omitEmpty: f.omitEmpty || err != nil})
+	}
+	if rows == nil {
+		return fn(merge(context, own))
+	}
+
+	context = merge(context, own)
+	list := v.FieldByIndex(rows.index)
+	for i := 0; i < list.Len(); i++ {
+		row := list.Index(i)
+		if row.Kind() != reflect.Map {
+			if err := eachRow(row, context, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		var cells []cell
+		if columns != nil {
+			for _, name := range v.FieldByIndex(columns.index).Interface().([]string) {
+				cells = append(cells, cell{name: name, value: row.MapIndex(reflect.ValueOf(name))})
+			}
+		}
+		if err := fn(merge(context, cells)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes a header line and one line per row.
+func writeCSV(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+	rv := reflect.ValueOf(v)
+	if err := cw.Write(header(rv.Type(), rv, nil)); err != nil {
+		return err
+	}
+	record := []string{}
+	err := eachRow(rv, nil, func(cells []cell) error {
+		record = record[:0]
+		for _, c := range cells {
+			record = append(record, text(c.value))
+		}
+		return cw.Write(record)
+	})
+	cw.Flush()
+	if err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeNDJSON writes one JSON object per row. As in the JSON format,
+// omitempty fields are left out when empty.
+func writeNDJSON(w io.Writer, v interface{}) error {
+	bw := bufio.NewWriter(w)
+	err := eachRow(reflect.ValueOf(v), nil, func(cells []cell) error {
+		bw.WriteByte('{')
+		first := true
+		for _, c := range cells {
+			if c.omitEmpty && (!c.value.IsValid() || c.value.IsZero()) {
+				continue
+			}
+			if !first {
+				bw.WriteByte(',')
+			}
+			first = false
+			name, _ := json.Marshal(c.name)
+			value, err := json.Marshal(interfaceOf(c.value))
+			if err != nil {
+				return err
+			}
+			bw.Write(name)
+			bw.WriteByte(':')
+			bw.Write(value)
+		}
+		bw.WriteString("}\n")
+		return nil
+	})
+	if flushErr := bw.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// text formats a cell for CSV. Lists of strings are joined with "|" and
+// other composite values are written as JSON.
+func text(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		if list, ok := v.Interface().([]string); ok {
+			return strings.Join(list, "|")
+		}
+	}
+	data, _ := json.Marshal(v.Interface())
+	return string(data)
+}
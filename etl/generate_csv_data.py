@@ -1,5 +1,6 @@
 import pandas as pd
 import os
+import re
 
 BASE_DIR = os.path.dirname(os.path.abspath(__file__))
 DATA_DIR = os.path.join(BASE_DIR, "data")
@@ -7,22 +8,28 @@ DATA_DIR = os.path.join(BASE_DIR, "data")
 # ===================== MAIN DATA =====================
 covid_data = "https://covid.ourworldindata.org/data/owid-covid-data.csv"
 df = pd.read_csv(covid_data)
-df = df[['iso_code', 'location', 'date', 'total_cases', 'total_deaths', 'people_vaccinated']]
+df = df[['iso_code', 'continent', 'location', 'date', 'total_cases', 'total_deaths',
+         'people_vaccinated', 'people_fully_vaccinated', 'total_boosters', 'total_vaccinations',
+         'hosp_patients', 'icu_patients', 'weekly_hosp_admissions', 'total_tests', 'positive_rate']]
 df = df[df['iso_code'].str.len() == 3]  # Filter valid ISO country codes
 
-# Assign unique IDs to each CovidCase and VaccinationStats entry (based on country/date rows)
+# Assign unique IDs to each CovidCase, VaccinationStats, HospitalStats and
+# TestingStats entry (based on country/date rows)
 df = df.reset_index(drop=True)
 df['covidcase_id'] = range(1, len(df) + 1)
 df['vaccstats_id'] = range(1, len(df) + 1)
+df['hospstats_id'] = range(1, len(df) + 1)
+df['teststats_id'] = range(1, len(df) + 1)
 
 # ===================== NODE: Country =====================
-countries = df[['iso_code', 'location']].drop_duplicates().copy()
+countries = df[['iso_code', 'location', 'continent']].drop_duplicates(subset=['iso_code']).copy()
 countries['id'] = range(1, len(countries) + 1)
 countries.rename(columns={
     'iso_code': 'iso3',
-    'location': 'name'
+    'location': 'name',
+    'continent': 'region'
 }, inplace=True)
-countries = countries[['id', 'name', 'iso3']]
+countries = countries[['id', 'name', 'iso3', 'region']]
 countries.to_csv(f"{DATA_DIR}/countries.csv", index=False)
 print(f"Saving countries.csv with {len(countries)} rows...")
 
@@ -39,17 +46,117 @@ covid_cases.to_csv(f"{DATA_DIR}/covid_cases.csv", index=False)
 print(f"Saving covid_cases.csv with {len(covid_cases)} rows...")
 
 # ===================== NODE: VaccinationStats =====================
-df_vacc = df.dropna(subset=['iso_code', 'date', 'people_vaccinated'], how='any').copy()
-vacc_stats = df_vacc[['vaccstats_id', 'iso_code', 'date', 'people_vaccinated']].copy()
+# Countries report each dose series on different days, so a record is kept
+# when at least one of them is present and the others stay empty.
+vacc_columns = {
+    'people_vaccinated': 'totalVaccinated',
+    'people_fully_vaccinated': 'totalFullyVaccinated',
+    'total_boosters': 'totalBoosters',
+    'total_vaccinations': 'totalDoses',
+}
+df_vacc = df.dropna(subset=['iso_code', 'date']).dropna(subset=list(vacc_columns), how='all').copy()
+vacc_stats = df_vacc[['vaccstats_id', 'iso_code', 'date', *vacc_columns]].copy()
 vacc_stats.rename(columns={
     'vaccstats_id': 'id',
     'iso_code': 'country_iso',
-    'people_vaccinated': 'totalVaccinated'
+    **vacc_columns
 }, inplace=True)
-vacc_stats['totalVaccinated'] = vacc_stats['totalVaccinated'].astype(int)
+for column in vacc_columns.values():
+    vacc_stats[column] = vacc_stats[column].astype('Int64')
 vacc_stats.to_csv(f"{DATA_DIR}/vaccination_stats.csv", index=False)
 print(f"Saving vaccination_stats.csv with {len(vacc_stats)} rows...")
 
+# ===================== NODE: HospitalStats =====================
+# Readings of patients in hospital and in ICUs and of weekly admissions,
+# reported by fewer countries and less often than cases.
+hosp_columns = {
+    'hosp_patients': 'hospPatients',
+    'icu_patients': 'icuPatients',
+    'weekly_hosp_admissions': 'weeklyHospAdmissions',
+}
+df_hosp = df.dropna(subset=['iso_code', 'date']).dropna(subset=list(hosp_columns), how='all').copy()
+hosp_stats = df_hosp[['hospstats_id', 'iso_code', 'date', *hosp_columns]].copy()
+hosp_stats.rename(columns={
+    'hospstats_id': 'id',
+    'iso_code': 'country_iso',
+    **hosp_columns
+}, inplace=True)
+for column in hosp_columns.values():
+    hosp_stats[column] = hosp_stats[column].round().astype('Int64')
+hosp_stats.to_csv(f"{DATA_DIR}/hospital_stats.csv", index=False)
+print(f"Saving hospital_stats.csv with {len(hosp_stats)} rows...")
+
+# ===================== NODE: TestingStats =====================
+# Cumulative tests and the positive rate (a 7-day rolling share of positive
+# tests, kept as a float).
+df_tests = df.dropna(subset=['iso_code', 'date']).dropna(subset=['total_tests', 'positive_rate'], how='all').copy()
+test_stats = df_tests[['teststats_id', 'iso_code', 'date', 'total_tests', 'positive_rate']].copy()
+test_stats.rename(columns={
+    'teststats_id': 'id',
+    'iso_code': 'country_iso',
+    'total_tests': 'totalTests',
+    'positive_rate': 'positiveRate'
+}, inplace=True)
+test_stats['totalTests'] = test_stats['totalTests'].astype('Int64')
+test_stats.to_csv(f"{DATA_DIR}/testing_stats.csv", index=False)
+print(f"Saving testing_stats.csv with {len(test_stats)} rows...")
+
+# ===================== NODES: GlobalDailyStats / RegionDailyStats =====================
+# Worldwide and per-region sums for every calendar day. Totals have
+# carry-forward semantics: a country without a value on a given day
+# contributes its most recent earlier value, which is also what the API reads
+# for a single country. New values are the day-over-day differences of the
+# summed totals. Readings (hospital patients, admissions) describe a single
+# day, so they are not carried forward: a day sums the readings reported on
+# it, and is left empty when no country reported any. The positive rate
+# cannot be summed over countries and is left out.
+def by_country(frame, column, dates):
+    values = frame[['country_iso', 'date', column]].dropna(subset=[column])
+    wide = values.pivot(index='date', columns='country_iso', values=column)
+    wide.index = pd.to_datetime(wide.index)
+    return wide.reindex(dates)
+
+def carried_forward(frame, column, dates):
+    return by_country(frame, column, dates).ffill().fillna(0)
+
+all_dates = pd.to_datetime(pd.concat([covid_cases['date'], vacc_stats['date'],
+                                      hosp_stats['date'], test_stats['date']]))
+dates = pd.date_range(all_dates.min(), all_dates.max(), freq='D')
+carried = {
+    'totalCases': carried_forward(covid_cases, 'totalCases', dates),
+    'totalDeaths': carried_forward(covid_cases, 'totalDeaths', dates),
+    **{column: carried_forward(vacc_stats, column, dates) for column in vacc_columns.values()},
+    'totalTests': carried_forward(test_stats, 'totalTests', dates),
+}
+readings = {column: by_country(hosp_stats, column, dates) for column in hosp_columns.values()}
+new_columns = {total: 'new' + total[len('total'):] for total in carried if total.startswith('total')}
+
+def daily_aggregates(isos):
+    stats = pd.DataFrame(index=dates)
+    for column, wide in carried.items():
+        stats[column] = wide.reindex(columns=isos, fill_value=0).sum(axis=1).astype('int64')
+    for column, wide in readings.items():
+        stats[column] = wide.reindex(columns=isos).sum(axis=1, min_count=1).astype('Int64')
+    for total, new in new_columns.items():
+        stats[new] = stats[total].diff().fillna(stats[total]).astype('int64')
+    stats.index.name = 'date'
+    stats = stats.reset_index()
+    stats['date'] = stats['date'].dt.strftime('%Y-%m-%d')
+    return stats
+
+global_stats = daily_aggregates(countries['iso3'])
+global_stats.to_csv(f"{DATA_DIR}/global_daily_stats.csv", index=False)
+print(f"Saving global_daily_stats.csv with {len(global_stats)} rows...")
+
+region_frames = []
+for region, members in countries.dropna(subset=['region']).groupby('region'):
+    stats = daily_aggregates(members['iso3'])
+    stats.insert(0, 'region', region)
+    region_frames.append(stats)
+region_stats = pd.concat(region_frames, ignore_index=True)
+region_stats.to_csv(f"{DATA_DIR}/region_daily_stats.csv", index=False)
+print(f"Saving region_daily_stats.csv with {len(region_stats)} rows...")
+
 # ===================== RELATIONSHIP: HAS_CASE =====================
 has_case = covid_cases[['country_iso', 'id']].rename(columns={
     'id': 'covidcase_id'
@@ -64,6 +171,20 @@ vaccinated_on = vacc_stats[['country_iso', 'id']].rename(columns={
 vaccinated_on.to_csv(f"{DATA_DIR}/vaccinated_on.csv", index=False)
 print(f"Saving vaccinated_on.csv with {len(vaccinated_on)} rows...")
 
+# ===================== RELATIONSHIP: HAS_HOSPITAL_STATS =====================
+has_hospital_stats = hosp_stats[['country_iso', 'id']].rename(columns={
+    'id': 'hospstats_id'
+})
+has_hospital_stats.to_csv(f"{DATA_DIR}/has_hospital_stats.csv", index=False)
+print(f"Saving has_hospital_stats.csv with {len(has_hospital_stats)} rows...")
+
+# ===================== RELATIONSHIP: HAS_TESTING_STATS =====================
+has_testing_stats = test_stats[['country_iso', 'id']].rename(columns={
+    'id': 'teststats_id'
+})
+has_testing_stats.to_csv(f"{DATA_DIR}/has_testing_stats.csv", index=False)
+print(f"Saving has_testing_stats.csv with {len(has_testing_stats)} rows...")
+
 # ===================== VACCINE MANUFACTURER DATA =====================
 vac_manuf_url = "https://covid.ourworldindata.org/data/vaccinations/vaccinations-by-manufacturer.csv"
 df_vac_by_manuf = pd.read_csv(vac_manuf_url)
@@ -79,13 +200,58 @@ df_vac_br['location'] = 'Brazil'
 df_vac_br = df_vac_br[['location', 'vaccine', 'date']]
 df_vac_by_manuf = pd.concat([df_vac_by_manuf, df_vac_br], ignore_index=True)
 
+# ===================== VACCINE ALIASES =====================
+# Sources spell the same vaccine differently (brand, manufacturer or
+# technical names). The curated alias table maps every known spelling,
+# case-insensitively, to the canonical OWID manufacturer name.
+aliases = pd.read_csv(f"{BASE_DIR}/vaccine_aliases.csv")
+canonical = {alias.lower(): vaccine for alias, vaccine in zip(aliases['alias'], aliases['vaccine'])}
+spellings = df_vac_by_manuf['vaccine'].unique()
+df_vac_by_manuf['vaccine'] = df_vac_by_manuf['vaccine'].map(lambda v: canonical.get(v.lower(), v))
+
+def slugify(name):
+    # "Pfizer/BioNTech" -> "pfizer-biontech", "Johnson&Johnson" -> "johnson-johnson"
+    return re.sub(r'[^a-z0-9]+', '-', name.lower()).strip('-')
+
 # ===================== NODE: Vaccine =====================
 vaccines = df_vac_by_manuf.groupby('vaccine')['date'].min().reset_index().copy()
 vaccines['id'] = range(1, len(vaccines) + 1)
 vaccines.rename(columns={'date': 'first_global_use'}, inplace=True)
+
+# The slug identifies a vaccine across reloads, unlike the row-order id
+vaccines['slug'] = vaccines['vaccine'].map(slugify)
+duplicated = vaccines[vaccines['slug'].duplicated(keep=False)]
+if not duplicated.empty:
+    raise ValueError(f"Vaccine slugs must be unique: {duplicated[['vaccine', 'slug']].to_dict('records')}")
+
+# Known aliases: the curated ones plus any source spelling mapped to the vaccine
+known = pd.concat([
+    aliases,
+    pd.DataFrame({'alias': spellings, 'vaccine': [canonical.get(v.lower(), v) for v in spellings]}),
+])
+known = known[known['alias'] != known['vaccine']].drop_duplicates()
+vaccines['aliases'] = vaccines['vaccine'].map(
+    lambda v: '|'.join(sorted(known.loc[known['vaccine'] == v, 'alias'])))
+
+# Curated metadata (manufacturers, platform and dose schedule), which the
+# OWID files do not carry. Vaccines missing from the file keep empty values.
+metadata = pd.read_csv(f"{BASE_DIR}/vaccine_metadata.csv")
+vaccines = vaccines.merge(metadata, on='vaccine', how='left')
+vaccines['doses'] = vaccines['doses'].astype('Int64')
+vaccines['dose_interval_days'] = vaccines['dose_interval_days'].astype('Int64')
+missing_metadata = vaccines.loc[vaccines['platform'].isna(), 'vaccine']
+if not missing_metadata.empty:
+    print(f"WARNING! Vaccines without curated metadata: {sorted(missing_metadata)}")
 vaccines.to_csv(f"{DATA_DIR}/vaccines.csv", index=False)
 print(f"Saving vaccines.csv with {len(vaccines)} rows...")
 
+# ===================== NODE: Approval =====================
+# Curated WHO EUL and regulatory approval dates, one row per vaccine and authority
+approvals = pd.read_csv(f"{BASE_DIR}/vaccine_approvals.csv")
+approvals = approvals[approvals['vaccine'].isin(vaccines['vaccine'])]
+approvals.to_csv(f"{DATA_DIR}/vaccine_approvals.csv", index=False)
+print(f"Saving vaccine_approvals.csv with {len(approvals)} rows...")
+
 # ===================== RELATIONSHIP: USES =====================
 # Map country name to ISO code
 country_iso_map = countries.set_index('name')['iso3'].to_dict()
@@ -107,3 +273,15 @@ uses.rename(columns={'date': 'first_used'}, inplace=True)
 
 uses.to_csv(f"{DATA_DIR}/uses.csv", index=False)
 print(f"Saving uses.csv with {len(uses)} rows...")
+
+# ===================== NODE: VaccineDoses =====================
+# Cumulative doses per country, vaccine and date. The Brazil data added
+# above only lists the vaccines in use, so it carries no doses.
+doses = uses_raw_valid.join(df_vac_by_manuf['total_vaccinations']).dropna(subset=['total_vaccinations'])
+doses = doses[['country_iso', 'vaccine', 'date', 'total_vaccinations']].copy()
+doses.rename(columns={'total_vaccinations': 'totalDoses'}, inplace=True)
+doses['totalDoses'] = doses['totalDoses'].astype('int64')
+doses = doses.sort_values(['country_iso', 'vaccine', 'date']).reset_index(drop=True)
+doses.insert(0, 'id', range(1, len(doses) + 1))
+doses.to_csv(f"{DATA_DIR}/vaccine_doses.csv", index=False)
+print(f"Saving vaccine_doses.csv with {len(doses)} rows...")
@@ -1,3 +1,4 @@
+import hashlib
 import os
 import pandas as pd
 from neo4j import GraphDatabase
@@ -15,13 +16,133 @@ DATA_DIR = os.path.join(BASE_DIR, "data")
 
 driver = GraphDatabase.driver(NEO4J_URI, auth=(NEO4J_USER, NEO4J_PASSWORD))
 
+def dataset_version():
+    # The version is a hash of the loaded CSV files, so reloading the same
+    # data keeps the API cache (and clients' ETags) valid.
+    digest = hashlib.sha256()
+    for name in sorted(os.listdir(DATA_DIR)):
+        if name.endswith(".csv"):
+            digest.update(name.encode())
+            with open(os.path.join(DATA_DIR, name), "rb") as f:
+                for block in iter(lambda: f.read(1 << 20), b""):
+                    digest.update(block)
+    return digest.hexdigest()[:16]
+
+def clean_nulls(row):
+    # pandas reads empty cells as NaN, which Neo4j would store as a float
+    return {key: (None if pd.isna(value) else value) for key, value in row.items()}
+
+def link_time_series(session, relationship, label, latest_relationship):
+    # Chains each country's stat nodes in date order with NEXT relationships
+    # and points the country at its most recent node, so that the previous
+    # record is one hop away and the latest one needs no sorting.
+    # Existing links are rebuilt, since a reload may insert nodes in between.
+    session.run(f"""
+        MATCH (:{label})-[n:NEXT]->(:{label})
+        CALL {{ WITH n DELETE n }} IN TRANSACTIONS OF 10000 ROWS
+    """)
+    session.run(f"""
+        MATCH (:Country)-[l:{latest_relationship}]->()
+        DELETE l
+    """)
+    session.run(f"""
+        MATCH (c:Country)
+        CALL {{
+            WITH c
+            MATCH (c)-[:{relationship}]->(s:{label})
+            WITH c, s ORDER BY s.date
+            WITH c, collect(s) AS series
+            FOREACH (i IN range(0, size(series) - 2) |
+                FOREACH (a IN [series[i]] | FOREACH (b IN [series[i + 1]] | MERGE (a)-[:NEXT]->(b))))
+            WITH c, series
+            WHERE size(series) > 0
+            WITH c, series[size(series) - 1] AS latest
+            MERGE (c)-[:{latest_relationship}]->(latest)
+        }} IN TRANSACTIONS OF 10 ROWS
+    """)
+
+# (relationship, label, property) of every per-country value summed into the daily aggregates
+# Totals are carried forward; readings only count on the day they were reported
+AGGREGATED_PROPERTIES = [
+    ("HAS_CASE", "CovidCase", "totalCases", "total"),
+    ("HAS_CASE", "CovidCase", "totalDeaths", "total"),
+    ("VACCINATED_ON", "VaccinationStats", "totalVaccinated", "total"),
+    ("VACCINATED_ON", "VaccinationStats", "totalFullyVaccinated", "total"),
+    ("VACCINATED_ON", "VaccinationStats", "totalBoosters", "total"),
+    ("VACCINATED_ON", "VaccinationStats", "totalDoses", "total"),
+    ("HAS_HOSPITAL_STATS", "HospitalStats", "hospPatients", "reading"),
+    ("HAS_HOSPITAL_STATS", "HospitalStats", "icuPatients", "reading"),
+    ("HAS_HOSPITAL_STATS", "HospitalStats", "weeklyHospAdmissions", "reading"),
+    ("HAS_TESTING_STATS", "TestingStats", "totalTests", "total"),
+]
+
+def check_global_aggregates(session, samples=12):
+    # Compares the precomputed GlobalDailyStats with the on-the-fly
+    # aggregation over every Country -> per-country stats pair,
+    # for a sample of dates spread over the whole period.
+    dates = [record["date"] for record in session.run(
+        """
+        MATCH (g:GlobalDailyStats)
+        WITH g ORDER BY g.date
+        WITH collect(g.date) AS dates
+        UNWIND range(0, $samples - 1) AS i
+        RETURN DISTINCT dates[toInteger(i * (size(dates) - 1) / ($samples - 1))] AS date
+        """,
+        samples=samples
+    )]
+
+    mismatches = 0
+    for day in dates:
+        computed = {}
+        for relationship, label, prop, kind in AGGREGATED_PROPERTIES:
+            if kind == "reading":
+                # Readings reported on the date, or null when there are none
+                computed[prop] = session.run(
+                    f"""
+                    MATCH (c:Country)-[:{relationship}]->(s:{label})
+                    WHERE s.date = $date AND s.{prop} IS NOT NULL
+                    RETURN CASE WHEN count(s) > 0 THEN sum(s.{prop}) END AS total
+                    """,
+                    date=day
+                ).single()["total"]
+                continue
+            # Each country contributes its latest non-null value on or before the date
+            computed[prop] = session.run(
+                f"""
+                MATCH (c:Country)-[:{relationship}]->(s:{label})
+                WHERE s.date <= $date AND s.{prop} IS NOT NULL
+                WITH c, s ORDER BY s.date DESC
+                WITH c, collect(s)[0] AS latest
+                RETURN sum(latest.{prop}) AS total
+                """,
+                date=day
+            ).single()["total"]
+        precomputed = session.run(
+            "MATCH (g:GlobalDailyStats {date: $date}) RETURN g",
+            date=day
+        ).single()["g"]
+        differing = {prop: (precomputed.get(prop), total) for prop, total in computed.items()
+                     if precomputed.get(prop) != total}
+        if differing:
+            mismatches += 1
+            print(f"WARNING! GlobalDailyStats mismatch on {day} (precomputed, on-the-fly): {differing}")
+
+    print(f"Consistency check of GlobalDailyStats: {len(dates) - mismatches}/{len(dates)} sampled dates match.")
+
+def require_schema(session):
+    # The schema (indexes and constraints) is managed only by the API's
+    # migrations (api/migrations), so that the ETL and the API cannot drift.
+    # `make etl-load` applies them before loading.
+    version = session.run(
+        "MATCH (m:SchemaMigration) RETURN coalesce(max(m.version), 0) AS version"
+    ).single()["version"]
+    if version < 1:
+        raise SystemExit("The database schema is not migrated. Run `make migrate` before loading.")
+
 def load_csv_to_neo4j():
     with driver.session() as session:
-        # Create indexes
-        session.run("CREATE INDEX IF NOT EXISTS FOR (c:Country) ON (c.iso3)")
-        session.run("CREATE INDEX IF NOT EXISTS FOR (cc:CovidCase) ON (cc.id)")
-        session.run("CREATE INDEX IF NOT EXISTS FOR (vs:VaccinationStats) ON (vs.id)")
-        session.run("CREATE INDEX IF NOT EXISTS FOR (v:Vaccine) ON (v.name)")
+        # Indexes and constraints come from the API's schema migrations
+        require_schema(session)
 
         # Load Country nodes
         for chunk in pd.read_csv(f"{DATA_DIR}/countries.csv", chunksize=1000):
@@ -30,9 +151,13 @@ def load_csv_to_neo4j():
                 """
                 UNWIND $batch AS row
                 MERGE (c:Country {iso3: row.iso3})
-                SET c.name = row.name, c.id = toInteger(row.id)
+                SET c.name = row.name, c.id = toInteger(row.id), c.region = row.region
+                WITH c, row
+                WHERE row.region IS NOT NULL
+                MERGE (r:Region {name: row.region})
+                MERGE (c)-[:IN_REGION]->(r)
                 """,
-                batch=countries
+                batch=[clean_nulls(row) for row in countries]
             )
 
         # Load CovidCase nodes
@@ -42,7 +167,8 @@ def load_csv_to_neo4j():
                 """
                 UNWIND $batch AS row
                 MERGE (cc:CovidCase {id: toInteger(row.id)})
-                SET cc.date = date(row.date),
+                SET cc.country = row.country_iso,
+                    cc.date = date(row.date),
                     cc.totalCases = CASE WHEN row.totalCases IS NOT NULL THEN toInteger(row.totalCases) ELSE NULL END,
                     cc.totalDeaths = CASE WHEN row.totalDeaths IS NOT NULL THEN toInteger(row.totalDeaths) ELSE NULL END
                 """,
@@ -56,10 +182,45 @@ def load_csv_to_neo4j():
                 """
                 UNWIND $batch AS row
                 MERGE (vs:VaccinationStats {id: toInteger(row.id)})
-                SET vs.date = date(row.date),
-                    vs.totalVaccinated = toInteger(row.totalVaccinated)
+                SET vs.country = row.country_iso,
+                    vs.date = date(row.date),
+                    vs.totalVaccinated = toInteger(row.totalVaccinated),
+                    vs.totalFullyVaccinated = toInteger(row.totalFullyVaccinated),
+                    vs.totalBoosters = toInteger(row.totalBoosters),
+                    vs.totalDoses = toInteger(row.totalDoses)
                 """,
-                batch=vacc_stats
+                batch=[clean_nulls(row) for row in vacc_stats]
+            )
+
+        # Load HospitalStats nodes
+        for chunk in pd.read_csv(f"{DATA_DIR}/hospital_stats.csv", chunksize=1000):
+            hosp_stats = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MERGE (hs:HospitalStats {id: toInteger(row.id)})
+                SET hs.country = row.country_iso,
+                    hs.date = date(row.date),
+                    hs.hospPatients = toInteger(row.hospPatients),
+                    hs.icuPatients = toInteger(row.icuPatients),
+                    hs.weeklyHospAdmissions = toInteger(row.weeklyHospAdmissions)
+                """,
+                batch=[clean_nulls(row) for row in hosp_stats]
+            )
+
+        # Load TestingStats nodes
+        for chunk in pd.read_csv(f"{DATA_DIR}/testing_stats.csv", chunksize=1000):
+            test_stats = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MERGE (ts:TestingStats {id: toInteger(row.id)})
+                SET ts.country = row.country_iso,
+                    ts.date = date(row.date),
+                    ts.totalTests = toInteger(row.totalTests),
+                    ts.positiveRate = toFloat(row.positiveRate)
+                """,
+                batch=[clean_nulls(row) for row in test_stats]
             )
 
         # Load Vaccine nodes
@@ -70,9 +231,98 @@ def load_csv_to_neo4j():
                 UNWIND $batch AS row
                 MERGE (v:Vaccine {name: row.vaccine})
                 SET v.id = toInteger(row.id),
-                    v.first_global_use = date(row.first_global_use)
+                    v.slug = row.slug,
+                    v.aliases = CASE WHEN row.aliases IS NULL THEN [] ELSE split(row.aliases, '|') END,
+                    v.first_global_use = date(row.first_global_use),
+                    v.doses = toInteger(row.doses),
+                    v.dose_interval_days = toInteger(row.dose_interval_days)
+                WITH v, row
+                CALL {
+                    WITH v, row
+                    WITH v, row WHERE row.platform IS NOT NULL
+                    MERGE (p:Platform {name: row.platform})
+                    MERGE (v)-[:USES_PLATFORM]->(p)
+                }
+                CALL {
+                    WITH v, row
+                    UNWIND CASE WHEN row.manufacturers IS NULL THEN [] ELSE split(row.manufacturers, '|') END AS manufacturer
+                    MERGE (m:Manufacturer {name: manufacturer})
+                    MERGE (v)-[:MADE_BY]->(m)
+                }
+                """,
+                batch=[clean_nulls(row) for row in vaccines]
+            )
+
+        # Approval nodes (curated WHO EUL and regulatory approvals)
+        for chunk in pd.read_csv(f"{DATA_DIR}/vaccine_approvals.csv", chunksize=1000):
+            approvals = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MATCH (v:Vaccine {name: row.vaccine})
+                MERGE (v)-[:HAS_APPROVAL]->(a:Approval {authority: row.authority})
+                SET a.type = row.type,
+                    a.date = date(row.date)
                 """,
-                batch=vaccines
+                batch=approvals
+            )
+
+        # Load GlobalDailyStats nodes (precomputed worldwide sums per date)
+        for chunk in pd.read_csv(f"{DATA_DIR}/global_daily_stats.csv", chunksize=1000):
+            global_stats = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MERGE (g:GlobalDailyStats {date: date(row.date)})
+                SET g.totalCases = toInteger(row.totalCases),
+                    g.totalDeaths = toInteger(row.totalDeaths),
+                    g.totalVaccinated = toInteger(row.totalVaccinated),
+                    g.totalFullyVaccinated = toInteger(row.totalFullyVaccinated),
+                    g.totalBoosters = toInteger(row.totalBoosters),
+                    g.totalDoses = toInteger(row.totalDoses),
+                    g.hospPatients = toInteger(row.hospPatients),
+                    g.icuPatients = toInteger(row.icuPatients),
+                    g.weeklyHospAdmissions = toInteger(row.weeklyHospAdmissions),
+                    g.totalTests = toInteger(row.totalTests),
+                    g.newCases = toInteger(row.newCases),
+                    g.newDeaths = toInteger(row.newDeaths),
+                    g.newVaccinated = toInteger(row.newVaccinated),
+                    g.newFullyVaccinated = toInteger(row.newFullyVaccinated),
+                    g.newBoosters = toInteger(row.newBoosters),
+                    g.newDoses = toInteger(row.newDoses),
+                    g.newTests = toInteger(row.newTests)
+                """,
+                batch=[clean_nulls(row) for row in global_stats]
+            )
+
+        # Load RegionDailyStats nodes (precomputed per-region sums per date)
+        for chunk in pd.read_csv(f"{DATA_DIR}/region_daily_stats.csv", chunksize=1000):
+            region_stats = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MATCH (r:Region {name: row.region})
+                MERGE (rs:RegionDailyStats {region: row.region, date: date(row.date)})
+                SET rs.totalCases = toInteger(row.totalCases),
+                    rs.totalDeaths = toInteger(row.totalDeaths),
+                    rs.totalVaccinated = toInteger(row.totalVaccinated),
+                    rs.totalFullyVaccinated = toInteger(row.totalFullyVaccinated),
+                    rs.totalBoosters = toInteger(row.totalBoosters),
+                    rs.totalDoses = toInteger(row.totalDoses),
+                    rs.hospPatients = toInteger(row.hospPatients),
+                    rs.icuPatients = toInteger(row.icuPatients),
+                    rs.weeklyHospAdmissions = toInteger(row.weeklyHospAdmissions),
+                    rs.totalTests = toInteger(row.totalTests),
+                    rs.newCases = toInteger(row.newCases),
+                    rs.newDeaths = toInteger(row.newDeaths),
+                    rs.newVaccinated = toInteger(row.newVaccinated),
+                    rs.newFullyVaccinated = toInteger(row.newFullyVaccinated),
+                    rs.newBoosters = toInteger(row.newBoosters),
+                    rs.newDoses = toInteger(row.newDoses),
+                    rs.newTests = toInteger(row.newTests)
+                MERGE (r)-[:HAS_DAILY_STATS]->(rs)
+                """,
+                batch=[clean_nulls(row) for row in region_stats]
             )
 
         # Relationships: HAS_CASE
@@ -101,6 +351,32 @@ def load_csv_to_neo4j():
                 batch=vaccinated_on
             )
 
+        # Relationships: HAS_HOSPITAL_STATS
+        for chunk in pd.read_csv(f"{DATA_DIR}/has_hospital_stats.csv", chunksize=1000):
+            has_hospital_stats = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MATCH (c:Country {iso3: row.country_iso})
+                MATCH (hs:HospitalStats {id: toInteger(row.hospstats_id)})
+                MERGE (c)-[:HAS_HOSPITAL_STATS]->(hs)
+                """,
+                batch=has_hospital_stats
+            )
+
+        # Relationships: HAS_TESTING_STATS
+        for chunk in pd.read_csv(f"{DATA_DIR}/has_testing_stats.csv", chunksize=1000):
+            has_testing_stats = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MATCH (c:Country {iso3: row.country_iso})
+                MATCH (ts:TestingStats {id: toInteger(row.teststats_id)})
+                MERGE (c)-[:HAS_TESTING_STATS]->(ts)
+                """,
+                batch=has_testing_stats
+            )
+
         # Relationships: USES with attribute
         for chunk in pd.read_csv(f"{DATA_DIR}/uses.csv", chunksize=1000):
             uses = chunk.to_dict(orient="records")
@@ -115,7 +391,46 @@ def load_csv_to_neo4j():
                 batch=uses
             )
 
-        print("Data successfully loaded into Neo4j.")
+        # VaccineDoses nodes, linked to their country and vaccine
+        for chunk in pd.read_csv(f"{DATA_DIR}/vaccine_doses.csv", chunksize=1000):
+            doses = chunk.to_dict(orient="records")
+            session.run(
+                """
+                UNWIND $batch AS row
+                MATCH (c:Country {iso3: row.country_iso})
+                MATCH (v:Vaccine {name: row.vaccine})
+                MERGE (d:VaccineDoses {id: toInteger(row.id)})
+                SET d.country = row.country_iso,
+                    d.vaccine = row.vaccine,
+                    d.date = date(row.date),
+                    d.totalDoses = toInteger(row.totalDoses)
+                MERGE (c)-[:ADMINISTERED]->(d)
+                MERGE (d)-[:OF_VACCINE]->(v)
+                """,
+                batch=doses
+            )
+
+        # Time index: NEXT chains and LATEST_* pointers per country
+        link_time_series(session, "HAS_CASE", "CovidCase", "LATEST_CASE")
+        link_time_series(session, "VACCINATED_ON", "VaccinationStats", "LATEST_VACCINATION")
+        link_time_series(session, "HAS_HOSPITAL_STATS", "HospitalStats", "LATEST_HOSPITAL_STATS")
+        link_time_series(session, "HAS_TESTING_STATS", "TestingStats", "LATEST_TESTING_STATS")
+
+        check_global_aggregates(session)
+
+        # Dataset version, used by the API to invalidate cached responses
+        version = dataset_version()
+        session.run(
+            """
+            MERGE (d:Dataset {name: 'viralgraph'})
+            WITH d, coalesce(d.version <> $version, true) AS changed
+            SET d.version = $version
+            SET d.loaded_at = CASE WHEN changed THEN datetime() ELSE d.loaded_at END
+            """,
+            version=version
+        )
+
+        print(f"Data successfully loaded into Neo4j (dataset version {version}).")
 
 if __name__ == "__main__":
     load_csv_to_neo4j()